@@ -1,26 +1,61 @@
 package configService
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
-
-	"gopkg.in/yaml.v2"
 )
 
+// Logger is used to report what the ConfigService does, e.g. which files it
+// searched and loaded
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type stdoutLogger struct{}
+
+func (stdoutLogger) Printf(format string, v ...interface{}) {
+	fmt.Printf(format, v...)
+}
+
 type ConfigService struct {
 	*Config
 	configModTimes map[string]time.Time
+	includedFiles  map[string]bool
+	dotenv         map[string]string
+	envNames       map[string]bool
+
+	subscriptionsMutex sync.Mutex
+	subscriptions      []*subscription
 }
 
 type Config struct {
-	Environment        string
+	// Environment is the current environment. Several environments can be
+	// stacked as comma separated list, e.g. "production,eu-west,canary".
+	// Later environments override earlier ones.
+	Environment string
+
+	// EnvironmentParents maps environments to the environment they extend,
+	// e.g. "staging" to "production". The configuration of a parent is
+	// loaded before the one of the environment extending it.
+	EnvironmentParents map[string]string
+
+	// Hostname is used to find host specific configuration files, e.g.
+	// config.production.<hostname>.yml. Defaults to the name of the host.
+	Hostname string
+
+	// InstanceID is used to find instance specific configuration files, e.g.
+	// config.<instance>.yml and config.production.<instance>.yml
+	InstanceID string
+
 	ENVPrefix          string
 	Debug              bool
 	Verbose            bool
@@ -29,10 +64,99 @@ type Config struct {
 	AutoReloadInterval time.Duration
 	AutoReloadCallback func(config interface{})
 
-	// In case of json files, this field will be used only when compiled with
-	// go 1.10 or later.
-	// This field will be ignored when compiled with go versions lower than 1.10.
+	// RestartRequiredCallback receives the fields tagged with
+	// `reload:"restart"` whose values changed on reload, they are logged if
+	// it is nil. The fields keep their old values.
+	RestartRequiredCallback func(fields []RestartRequiredField)
+
+	// DotenvFiles are dotenv files, e.g. ".env", whose variables are used
+	// like those of the shell environment, which take precedence over them.
+	// Each file is followed by its environment variants, e.g.
+	// .env.production, which override its variables.
+	DotenvFiles []string
+
+	// Logger receives all messages, defaults to printing them to stdout
+	Logger Logger
+
+	// SearchPaths are the paths Discover searches for configuration files,
+	// with the highest precedence first. "{name}" is replaced with the name
+	// passed to Discover, "~" with the home directory and environment
+	// variables are expanded. Defaults to DefaultSearchPaths.
+	SearchPaths []string
+
+	// DiscoverAll makes LoadDiscovered merge all configuration files found
+	// by Discover instead of loading the first one only
+	DiscoverAll bool
+
+	// ErrorOnUnmatchedKeys makes Load return an UnknownKeysError if
+	// configuration files contain keys which don't match any field of the
+	// config struct
 	ErrorOnUnmatchedKeys bool
+
+	// WarnOnUnmatchedKeys logs the keys of configuration files which don't
+	// match any field of the config struct instead of failing
+	WarnOnUnmatchedKeys bool
+
+	// ErrorOnUnmatchedEnv makes Load return an UnknownEnvError if there are
+	// environment variables starting with the ENVPrefix which don't match any
+	// field of the config struct
+	ErrorOnUnmatchedEnv bool
+
+	// WarnOnUnmatchedEnv logs the environment variables starting with the
+	// ENVPrefix which don't match any field of the config struct instead of
+	// failing
+	WarnOnUnmatchedEnv bool
+
+	// DeprecationHandler receives the warnings about deprecated keys and
+	// environment variables in use, they are logged if it is nil
+	DeprecationHandler func(DeprecationWarning)
+
+	// DeprecationCutoff makes deprecated keys and environment variables an
+	// error once it has passed
+	DeprecationCutoff time.Time
+
+	// Migrations maps versions of configuration files to the migration to
+	// the next version. Migrations run on each configuration file before it
+	// is decoded, one after another, until there is none for the version
+	// reached. Files without the VersionKey, e.g. override files holding a
+	// few keys only, aren't migrated.
+	Migrations map[int]Migration
+
+	// VersionKey is the key holding the version of configuration files, see
+	// Migrations. Defaults to "version".
+	VersionKey string
+
+	// PersistMigrations makes SetupConfig write existing configuration
+	// files back once they were migrated. Otherwise files are migrated
+	// while loading only, unless MigrateFile is called.
+	PersistMigrations bool
+
+	// LenientJSON allows comments, trailing commas, unquoted keys and single
+	// quoted strings in .json files, like in .jsonc and .json5 files
+	LenientJSON bool
+
+	// DefaultsFS holds default configuration files, e.g. embedded into the
+	// binary with go:embed. They are loaded with the lowest precedence,
+	// accompanied by their environment variants like files on disk.
+	DefaultsFS fs.FS
+
+	// DefaultsFiles are the names of the default configuration files inside
+	// DefaultsFS. They default to the base names of the files passed to Load.
+	DefaultsFiles []string
+
+	// Schema is a JSON Schema each configuration file is validated against
+	// before it is decoded
+	Schema []byte
+
+	// EnvironmentSections treats the top level keys of configuration files as
+	// sections. The "default" section is merged with the sections named after
+	// the current environments, all other sections are ignored. Files
+	// without any of these sections are loaded as they are.
+	EnvironmentSections bool
+
+	// ValidateSchema validates each configuration file against a schema
+	// generated from the config struct, if no Schema is set
+	ValidateSchema bool
 }
 
 // New initialize a ConfigService
@@ -53,6 +177,10 @@ func New(config *Config) *ConfigService {
 		config.Silent = true
 	}
 
+	if config.Logger == nil {
+		config.Logger = stdoutLogger{}
+	}
+
 	if config.AutoReload && config.AutoReloadInterval == 0 {
 		config.AutoReloadInterval = time.Second
 	}
@@ -78,6 +206,65 @@ func (configService *ConfigService) GetEnvironment() string {
 	return configService.Environment
 }
 
+// GetEnvironments returns the stacked environments in the order their
+// configurations are applied, with parents preceding the environments
+// extending them
+func (configService *ConfigService) GetEnvironments() []string {
+	var environments []string
+	added := map[string]bool{}
+
+	for _, env := range strings.Split(configService.GetEnvironment(), ",") {
+		env = strings.TrimSpace(env)
+
+		// collect the ancestors of the environment, stopping at cycles
+		var chain []string
+		visited := map[string]bool{}
+		for current := env; current != "" && !visited[current]; current = configService.EnvironmentParents[current] {
+			visited[current] = true
+			chain = append([]string{current}, chain...)
+		}
+
+		for _, current := range chain {
+			if !added[current] {
+				added[current] = true
+				environments = append(environments, current)
+			}
+		}
+	}
+	return environments
+}
+
+// GetHostname returns the hostname used to find host specific configuration
+// files
+func (configService *ConfigService) GetHostname() string {
+	if configService.Hostname == "" {
+		if hostname := os.Getenv("CONFIGOR_HOSTNAME"); hostname != "" {
+			return hostname
+		}
+
+		hostname, _ := os.Hostname()
+		return hostname
+	}
+	return configService.Hostname
+}
+
+// GetInstanceID returns the ID used to find instance specific configuration
+// files
+func (configService *ConfigService) GetInstanceID() string {
+	if configService.InstanceID == "" {
+		return os.Getenv("CONFIGOR_INSTANCE_ID")
+	}
+	return configService.InstanceID
+}
+
+func (configService *ConfigService) logf(format string, v ...interface{}) {
+	if configService.Config.Logger == nil {
+		stdoutLogger{}.Printf(format, v...)
+		return
+	}
+	configService.Config.Logger.Printf(format, v...)
+}
+
 // GetErrorOnUnmatchedKeys returns a boolean indicating if an error should be
 // thrown if there are keys in the config file that do not correspond to the
 // config struct
@@ -87,18 +274,12 @@ func (configService *ConfigService) GetErrorOnUnmatchedKeys() bool {
 
 //Save saves the config file
 func (configService *ConfigService) Save(config interface{}, filename string) error {
-	var js []byte
-	var err error
-
-	switch {
-	case strings.HasSuffix(filename, ".yaml") || strings.HasSuffix(filename, ".yml"):
-		js, err = yaml.Marshal(&config)
-	case strings.HasSuffix(filename, ".json"):
-		js, err = json.Marshal(&config)
-	default:
+	format := lookupFormat(fileFormat(filename))
+	if format == nil || format.encoder == nil {
 		return errors.New("Unknown file type")
 	}
 
+	js, err := format.encoder(config)
 	if err != nil {
 		return err
 	}
@@ -112,6 +293,9 @@ func (configService *ConfigService) Load(config interface{}, files ...string) (e
 	if !defaultValue.CanAddr() {
 		return fmt.Errorf("Config %v should be addressable", config)
 	}
+	// reloads start from the values before the first load, otherwise merged
+	// slices and maps would keep the values of earlier loads
+	initialValue := copyValue(defaultValue)
 	err, _ = configService.load(config, false, files...)
 
 	if configService.Config.AutoReload {
@@ -119,16 +303,20 @@ func (configService *ConfigService) Load(config interface{}, files ...string) (e
 			timer := time.NewTimer(configService.Config.AutoReloadInterval)
 			for range timer.C {
 				reflectPtr := reflect.New(reflect.ValueOf(config).Elem().Type())
-				reflectPtr.Elem().Set(defaultValue)
+				reflectPtr.Elem().Set(copyValue(initialValue))
+				previous := copyValue(reflect.ValueOf(config).Elem())
 
-				var changed bool
-				if err, changed = configService.load(reflectPtr.Interface(), true, files...); err == nil && changed {
+				// err is local, Load has already returned
+				if err, changed := configService.load(reflectPtr.Interface(), true, files...); err == nil && changed {
+					restartFields := keepRestartFields(previous, reflectPtr.Elem(), nil)
 					reflect.ValueOf(config).Elem().Set(reflectPtr.Elem())
+					configService.reportRestartRequired(restartFields)
+					configService.notifySubscribers(previous, reflect.ValueOf(config).Elem())
 					if configService.Config.AutoReloadCallback != nil {
 						configService.Config.AutoReloadCallback(config)
 					}
 				} else if err != nil {
-					fmt.Printf("Failed to reload configuration from %v, got error %v\n", files, err)
+					configService.logf("Failed to reload configuration from %v, got error %v\n", files, err)
 				}
 				timer.Reset(configService.Config.AutoReloadInterval)
 			}
@@ -137,6 +325,54 @@ func (configService *ConfigService) Load(config interface{}, files ...string) (e
 	return
 }
 
+// LoadBytes will unmarshal configurations to struct from data in the given
+// format, the same way Load does for files. The format is detected if it is
+// empty.
+func (configService *ConfigService) LoadBytes(config interface{}, data []byte, format Format) (err error) {
+	defaultValue := reflect.Indirect(reflect.ValueOf(config))
+	if !defaultValue.CanAddr() {
+		return fmt.Errorf("Config %v should be addressable", config)
+	}
+
+	defer func() {
+		if configService.Config.Debug || configService.Config.Verbose {
+			if err != nil {
+				configService.logf("Failed to load configuration from %v data, got %v\n", format, err)
+			}
+
+			configService.logf("Configuration:\n  %#v\n", config)
+		}
+	}()
+
+	validator, err := configService.getSchemaValidator(config)
+	if err != nil {
+		return err
+	}
+
+	if err = configService.processDefaultsFiles(config, nil, validator); err != nil {
+		return err
+	}
+
+	if err = configService.processData(config, diskFileSystem{}, "", data, format, validator, nil); err != nil {
+		return err
+	}
+
+	if err = configService.loadDotenv(nil); err != nil {
+		return err
+	}
+	return configService.processENVTags(config)
+}
+
+// LoadReader will unmarshal configurations to struct from the data read from
+// reader in the given format, the same way Load does for files
+func (configService *ConfigService) LoadReader(config interface{}, reader io.Reader, format Format) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return configService.LoadBytes(config, data, format)
+}
+
 // Init inits the config default values
 func (configService *ConfigService) Init(config interface{}, files ...string) (err error) {
 	defaultValue := reflect.Indirect(reflect.ValueOf(config))
@@ -148,6 +384,7 @@ func (configService *ConfigService) Init(config interface{}, files ...string) (e
 }
 
 //SetupConfig create config file if not exists and fill it with default values
+//Existing files are migrated if PersistMigrations is set
 //Returns true if config was created
 func (configService *ConfigService) SetupConfig(config interface{}, file string, initValues func(interface{}) interface{}) (bool, error) {
 	s, err := os.Stat(file)
@@ -161,7 +398,10 @@ func (configService *ConfigService) SetupConfig(config interface{}, file string,
 		return true, nil
 	}
 
-	return false, nil
+	if configService.Config.PersistMigrations {
+		_, err = configService.MigrateFile(file)
+	}
+	return false, err
 }
 
 // ENV return environment
@@ -174,6 +414,35 @@ func Load(config interface{}, files ...string) error {
 	return New(nil).Load(config, files...)
 }
 
+// MigrateFile runs the migrations on a configuration file and writes it back
+// if it was migrated
+func MigrateFile(file string, migrations map[int]Migration) (bool, error) {
+	return New(&Config{Migrations: migrations}).MigrateFile(file)
+}
+
+// Discover returns the configuration files found for name in the search paths
+func Discover(name string) []string {
+	return New(nil).Discover(name)
+}
+
+// LoadDiscovered will unmarshal configurations to struct from the files found
+// for name in the search paths
+func LoadDiscovered(config interface{}, name string) error {
+	return New(nil).LoadDiscovered(config, name)
+}
+
+// LoadBytes will unmarshal configurations to struct from data in the given
+// format
+func LoadBytes(config interface{}, data []byte, format Format) error {
+	return New(nil).LoadBytes(config, data, format)
+}
+
+// LoadReader will unmarshal configurations to struct from the data read from
+// reader in the given format
+func LoadReader(config interface{}, reader io.Reader, format Format) error {
+	return New(nil).LoadReader(config, reader, format)
+}
+
 //Save saves a config
 func Save(config interface{}, file string) error {
 	return New(nil).Save(config, file)
@@ -0,0 +1,331 @@
+package configService
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// DecodeError is returned by Load if configuration data can't be decoded
+type DecodeError struct {
+	// File is the configuration file, it is empty for data passed to
+	// LoadBytes and LoadReader
+	File   string
+	Format Format
+
+	// Line and Column locate the problem inside the file, starting at 1.
+	// They are 0 if the position is unknown.
+	Line   int
+	Column int
+
+	// Path is the dotted key path of the offending value, if it is known
+	Path string
+
+	// Snippet holds the lines of the file around Line
+	Snippet string
+
+	Err error
+
+	// transformed is set if the decoder got an encoded copy of the file,
+	// whose line numbers don't match those of the file
+	transformed bool
+}
+
+func (e *DecodeError) Error() string {
+	source := e.File
+	if source == "" {
+		source = strings.TrimSpace(fmt.Sprintf("%v data", e.Format))
+	}
+	if e.Line > 0 {
+		source = fmt.Sprintf("%v:%v:%v", source, e.Line, e.Column)
+	}
+	if e.Path != "" {
+		source = fmt.Sprintf("%v: %v", source, e.Path)
+	}
+	message := errorMessage(e.Err)
+	if e.transformed {
+		message = errorLinePrefixPattern.ReplaceAllString(message, "")
+	}
+	return fmt.Sprintf("failed to decode %v: %v", source, message)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// errorMessage returns the message of err without the position and key path,
+// which are part of the DecodeError
+func errorMessage(err error) string {
+	switch e := err.(type) {
+	case *syntaxError:
+		return e.message
+	case *keyError:
+		return e.err.Error()
+	case hcl.Diagnostics:
+		for _, diagnostic := range e {
+			if diagnostic.Severity == hcl.DiagError {
+				return fmt.Sprintf("%v; %v", diagnostic.Summary, diagnostic.Detail)
+			}
+		}
+	}
+	return err.Error()
+}
+
+// Positioner is implemented by errors which know the line and column of the
+// problem, starting at 1. DecodeError uses it to locate errors of decoders
+// of registered formats.
+type Positioner interface {
+	Position() (line, column int)
+}
+
+// syntaxError is an error at a certain line of configuration data
+type syntaxError struct {
+	line    int
+	message string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("line %v: %v", e.line, e.message)
+}
+
+func (e *syntaxError) Position() (int, int) {
+	return e.line, 0
+}
+
+// keyError is an error concerning the value at a key path
+type keyError struct {
+	keys []string
+	err  error
+}
+
+func keyErrorf(keys []string, format string, args ...interface{}) error {
+	return &keyError{keys: keys, err: fmt.Errorf(format, args...)}
+}
+
+func (e *keyError) Error() string {
+	return fmt.Sprintf("%v: %v", strings.Join(e.keys, "."), e.err)
+}
+
+func (e *keyError) Unwrap() error {
+	return e.err
+}
+
+var (
+	errorLinePattern        = regexp.MustCompile(`\bline (\d+)`)
+	errorColumnPattern      = regexp.MustCompile(`\bcolumn (\d+)`)
+	errorLinePrefixPattern  = regexp.MustCompile(`\bline \d+(, column \d+)?: `)
+	jsonUnknownFieldPattern = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+	yamlUnknownFieldPattern = regexp.MustCompile(`field (\S+) not found in type`)
+	tomlTypeMismatchPattern = regexp.MustCompile(`^Type mismatch for '([^']*)'`)
+)
+
+// newDecodeError describes the error decoding the data of a file. The data
+// is the one passed to the decoder, source the original data of the file,
+// which differ if the file was transformed. locate finds key paths in
+// source.
+func newDecodeError(file string, format Format, data, source []byte, err error, locate func([]string) (int, int, bool)) *DecodeError {
+	decodeError := &DecodeError{File: file, Format: format, Err: err}
+
+	line, column, path := errorPosition(format, data, err)
+	if !bytes.Equal(data, source) {
+		// positions inside transformed data don't help
+		line, column = 0, 0
+		decodeError.transformed = true
+	}
+	if line == 0 && len(path) > 0 && locate != nil {
+		line, column, _ = locate(path)
+	}
+
+	if line > 0 && column == 0 {
+		column = firstColumn(source, line)
+	}
+	decodeError.Line, decodeError.Column = line, column
+	decodeError.Path = strings.Join(path, ".")
+	decodeError.Snippet = snippet(source, line, column)
+	return decodeError
+}
+
+// errorPosition extracts the position and key path of a problem from the
+// errors of the decoders
+func errorPosition(format Format, data []byte, err error) (line, column int, path []string) {
+	switch e := err.(type) {
+	case Positioner:
+		line, column = e.Position()
+		return line, column, nil
+	case *keyError:
+		return 0, 0, e.keys
+	case *json.SyntaxError:
+		// the offset is behind the offending character
+		line, column = offsetPosition(data, maxInt(int(e.Offset)-1, 0))
+		return line, column, nil
+	case *json.UnmarshalTypeError:
+		if e.Field != "" {
+			// the offset is behind the value, the key is located instead
+			return 0, 0, strings.Split(e.Field, ".")
+		}
+		line, column = offsetPosition(data, int(e.Offset))
+		return line, column, nil
+	case *xml.SyntaxError:
+		return e.Line, 0, nil
+	case hcl.Diagnostics:
+		for _, diagnostic := range e {
+			if diagnostic.Severity == hcl.DiagError && diagnostic.Subject != nil {
+				return diagnostic.Subject.Start.Line, diagnostic.Subject.Start.Column, nil
+			}
+		}
+		return 0, 0, nil
+	case *UnmatchedTomlKeysError:
+		if len(e.Keys) > 0 {
+			return 0, 0, []string(e.Keys[0])
+		}
+		return 0, 0, nil
+	case *yaml.TypeError:
+		if len(e.Errors) > 0 {
+			return errorPosition(format, data, fmt.Errorf("%v", e.Errors[0]))
+		}
+		return 0, 0, nil
+	}
+
+	message := err.Error()
+	if match := jsonUnknownFieldPattern.FindStringSubmatch(message); match != nil {
+		// the path of unknown fields isn't known, use the first key of the name
+		if location := regexp.MustCompile(`"` + regexp.QuoteMeta(match[1]) + `"\s*:`).FindIndex(data); location != nil {
+			line, column = offsetPosition(data, location[0]+1)
+		}
+		return line, column, nil
+	}
+	if match := tomlTypeMismatchPattern.FindStringSubmatch(message); match != nil {
+		// the key is prefixed with the name of the config type
+		keys := strings.Split(match[1], ".")
+		if len(keys) > 2 {
+			return 0, 0, keys[2:]
+		}
+	}
+	if match := errorLinePattern.FindStringSubmatch(message); match != nil {
+		line, _ = strconv.Atoi(match[1])
+		if match := errorColumnPattern.FindStringSubmatch(message); match != nil {
+			column, _ = strconv.Atoi(match[1])
+		}
+		if match := yamlUnknownFieldPattern.FindStringSubmatch(message); match != nil && format == FormatYAML {
+			column = yamlKeyColumn(data, line, match[1])
+		}
+	}
+	return line, column, nil
+}
+
+// yamlKeyColumn returns the column of key in the given line, or 0
+func yamlKeyColumn(data []byte, line int, key string) int {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	return strings.Index(lines[line-1], key) + 1
+}
+
+// firstColumn returns the column of the first character of a line which
+// isn't whitespace
+func firstColumn(data []byte, line int) int {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	return len(lines[line-1]) - len(strings.TrimLeft(lines[line-1], " \t")) + 1
+}
+
+// snippet returns the line before, the line itself and the line after line,
+// marking the column
+func snippet(data []byte, line, column int) string {
+	lines := strings.Split(string(data), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+
+	var result strings.Builder
+	for number := line - 1; number <= line+1; number++ {
+		if number < 1 || number > len(lines) {
+			continue
+		}
+		fmt.Fprintf(&result, "%4d | %v\n", number, strings.TrimRight(lines[number-1], "\r"))
+		if number == line && column > 0 {
+			fmt.Fprintf(&result, "     | %v^\n", strings.Repeat(" ", column-1))
+		}
+	}
+	return result.String()
+}
+
+// documentMismatch returns the key path of the first value of the document
+// which the decoder of the format can't decode into its field, or nil. It is
+// used to locate type errors of decoders which don't tell the key.
+func documentMismatch(t reflect.Type, node interface{}, keys []string, format Format) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if node == nil || t.Kind() == reflect.Interface || decodesItself(t) {
+		return nil
+	}
+
+	fits := true
+	switch node := node.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			for _, key := range sortedKeys(node) {
+				if fieldStruct, ok := decoderField(t, key, format); ok {
+					if mismatch := documentMismatch(fieldStruct.Type, node[key], appendKey(keys, key), format); mismatch != nil {
+						return mismatch
+					}
+				}
+			}
+		case reflect.Map:
+			for _, key := range sortedKeys(node) {
+				if mismatch := documentMismatch(t.Elem(), node[key], appendKey(keys, key), format); mismatch != nil {
+					return mismatch
+				}
+			}
+		default:
+			fits = false
+		}
+	case []interface{}:
+		fits = t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+		for i := 0; fits && i < len(node); i++ {
+			if mismatch := documentMismatch(t.Elem(), node[i], appendKey(keys, strconv.Itoa(i)), format); mismatch != nil {
+				return mismatch
+			}
+		}
+	case string:
+		// yaml.v2 parses durations
+		fits = t.Kind() == reflect.String || (format == FormatYAML && t == durationType)
+	case bool:
+		fits = t.Kind() == reflect.Bool || (format == FormatYAML && t.Kind() == reflect.String)
+	default:
+		number, ok := toNumber(node)
+		if !ok {
+			return nil
+		}
+		switch {
+		case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+			fits = number == math.Trunc(number)
+		case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+			// toml doesn't convert integers
+			_, isInteger := node.(int64)
+			fits = format != FormatTOML || !isInteger
+		default:
+			// yaml.v2 decodes any scalar into strings
+			fits = format == FormatYAML && t.Kind() == reflect.String
+		}
+	}
+
+	if !fits {
+		return keys
+	}
+	return nil
+}
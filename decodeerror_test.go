@@ -0,0 +1,70 @@
+package configService
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type decodeErrorConfig struct {
+	Name   string
+	Server struct {
+		Host string
+		Port int `alias:"listen_port"`
+	}
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		data     string
+		sections bool
+		line     int
+		column   int
+		path     string
+	}{
+		{"yaml type", "config.yml", "name: app\nserver:\n  port: two\n", false, 3, 3, ""},
+		{"json syntax", "config.json", "{\n  \"name\": \"app\",,\n}", false, 2, 17, ""},
+		{"json type", "config.json", "{\n  \"server\": {\n    \"port\": \"two\"\n  }\n}", false, 3, 5, "server.port"},
+		{"toml type", "config.toml", "name = \"app\"\n\n[server]\nport = \"two\"\n", false, 4, 1, "server.port"},
+		{"environment sections", "config.yml", "default:\n  server:\n    port: 1\nproduction:\n  server:\n    host: h\n    port: two\n", true, 7, 5, "server.port"},
+		{"renamed alias", "config.yml", "name: app\nserver:\n  listen_port: two\n", false, 3, 3, "server.port"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTestFiles(t, map[string]string{test.file: test.data})
+			service := New(&Config{Environment: "production", EnvironmentSections: test.sections, Silent: true})
+			err := service.Load(&decodeErrorConfig{}, filepath.Join(dir, test.file))
+
+			decodeError, ok := err.(*DecodeError)
+			if !ok {
+				t.Fatalf("got %v, want a DecodeError", err)
+			}
+			if decodeError.Line != test.line || decodeError.Column != test.column || decodeError.Path != test.path {
+				t.Errorf("got %v:%v %q, want %v:%v %q", decodeError.Line, decodeError.Column, decodeError.Path, test.line, test.column, test.path)
+			}
+			if !strings.Contains(decodeError.Snippet, strings.Split(test.data, "\n")[test.line-1]) {
+				t.Errorf("got snippet\n%v", decodeError.Snippet)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorOfTransformedDocument(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml": "default:\n  server:\n    port: 1\nproduction:\n  server:\n    port: two\n",
+	})
+	service := New(&Config{Environment: "production", EnvironmentSections: true, Silent: true})
+	err := service.Load(&decodeErrorConfig{}, filepath.Join(dir, "config.yml"))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// the decoder reports the line inside the encoded document
+	message := err.Error()
+	if strings.Contains(message, "line 2") || !strings.Contains(message, "config.yml:6:5: server.port: yaml: unmarshal errors:") {
+		t.Errorf("got %q, want the position in the file only", message)
+	}
+}
@@ -0,0 +1,283 @@
+package configService
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeprecationWarning reports a deprecated key of a configuration file or a
+// deprecated environment variable in use. Keys are deprecated by the alias
+// tag of the field replacing them, e.g. `alias:"old_name,older_name"`, or by
+// the deprecated tag of their field, e.g. `deprecated:"use db.host instead"`.
+type DeprecationWarning struct {
+	// Key is the dotted key path or the name of the environment variable
+	Key string
+
+	// File is the configuration file, it is empty for environment variables
+	// and data passed to LoadBytes and LoadReader
+	File string
+
+	// Line and Column locate the key inside the file, starting at 1. They
+	// are 0 if the position is unknown.
+	Line   int
+	Column int
+
+	// Replacement is the key path or environment variable replacing an
+	// alias, it is empty if the field itself is deprecated
+	Replacement string
+
+	// Message is the deprecated tag of the field
+	Message string
+}
+
+func (warning DeprecationWarning) String() string {
+	result := fmt.Sprintf("%v is deprecated", warning.Key)
+	if warning.Replacement != "" {
+		result = fmt.Sprintf("%v, use %v instead", result, warning.Replacement)
+	}
+	if warning.Message != "" {
+		result = fmt.Sprintf("%v: %v", result, warning.Message)
+	}
+	if warning.File != "" {
+		if warning.Line > 0 {
+			return fmt.Sprintf("%v:%v:%v: %v", warning.File, warning.Line, warning.Column, result)
+		}
+		return fmt.Sprintf("%v: %v", warning.File, result)
+	}
+	return result
+}
+
+// DeprecationError is returned by Load if deprecated keys or environment
+// variables are in use after the DeprecationCutoff
+type DeprecationError struct {
+	Warnings []DeprecationWarning
+}
+
+func (e *DeprecationError) Error() string {
+	warnings := make([]string, len(e.Warnings))
+	for i, warning := range e.Warnings {
+		warnings[i] = warning.String()
+	}
+	return fmt.Sprintf("deprecated configuration in use:\n  %v", strings.Join(warnings, "\n  "))
+}
+
+// reportDeprecations passes the warnings to the DeprecationHandler or logs
+// them. Once the DeprecationCutoff has passed they are returned as error.
+func (configService *ConfigService) reportDeprecations(warnings []DeprecationWarning) error {
+	if len(warnings) == 0 {
+		return nil
+	}
+
+	if cutoff := configService.Config.DeprecationCutoff; !cutoff.IsZero() && time.Now().After(cutoff) {
+		return &DeprecationError{Warnings: warnings}
+	}
+
+	for _, warning := range warnings {
+		if configService.Config.DeprecationHandler != nil {
+			configService.Config.DeprecationHandler(warning)
+		} else if !configService.Silent {
+			configService.logf("Warning: %v\n", warning)
+		}
+	}
+	return nil
+}
+
+// fieldAliases returns the names given by the alias tag of the field
+func fieldAliases(fieldStruct *reflect.StructField) []string {
+	var aliases []string
+	for _, alias := range strings.Split(fieldStruct.Tag.Get("alias"), ",") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases
+}
+
+// documentKey returns the key the decoder of the format decodes into the
+// field: the yaml tag or the lower cased field name for YAML, the json or
+// toml tag or the field name for JSON and TOML
+func documentKey(fieldStruct *reflect.StructField, format Format) string {
+	switch format {
+	case FormatYAML:
+		return yamlKey(fieldStruct)
+	case FormatJSON, FormatJSONC, FormatTOML:
+		tag := string(format)
+		if format == FormatJSONC {
+			tag = string(FormatJSON)
+		}
+		if name := tagKey(fieldStruct, tag); name != "" {
+			return name
+		}
+		return fieldStruct.Name
+	}
+	return configKey(fieldStruct)
+}
+
+// aliasResolver renames the aliases of fields in a document to the keys of
+// the fields and collects warnings for deprecated keys
+type aliasResolver struct {
+	format   Format
+	file     string
+	locate   func([]string) (int, int, bool)
+	warnings []DeprecationWarning
+	modified bool
+
+	// renames maps the renamed key paths to those in the file
+	renames map[string][]string
+}
+
+// resolveAliases renames the aliases in the document of file. It returns
+// whether the document was modified and a locate function which finds the
+// renamed keys.
+func (configService *ConfigService) resolveAliases(config interface{}, file string, format Format, document interface{}, locate func([]string) (int, int, bool)) (bool, func([]string) (int, int, bool), error) {
+	resolver := &aliasResolver{format: format, file: file, locate: locate, renames: map[string][]string{}}
+	resolver.resolve(reflect.TypeOf(config), document, nil, nil)
+	if err := configService.reportDeprecations(resolver.warnings); err != nil {
+		return false, nil, err
+	}
+
+	if len(resolver.renames) == 0 {
+		return resolver.modified, locate, nil
+	}
+	return resolver.modified, resolver.locateRenamed, nil
+}
+
+func (resolver *aliasResolver) locateRenamed(path []string) (int, int, bool) {
+	for i := len(path); i > 0; i-- {
+		if source, ok := resolver.renames[strings.Join(path[:i], ".")]; ok {
+			return resolver.locate(append(append([]string{}, source...), path[i:]...))
+		}
+	}
+	return resolver.locate(path)
+}
+
+func (resolver *aliasResolver) warn(sourceKeys []string, replacement string, message string) {
+	warning := DeprecationWarning{File: resolver.file, Key: strings.Join(sourceKeys, "."), Replacement: replacement, Message: message}
+	warning.Line, warning.Column, _ = resolver.locate(sourceKeys)
+	resolver.warnings = append(resolver.warnings, warning)
+}
+
+// resolve walks the document alongside the config type. keys is the path of
+// node in the document, sourceKeys its path in the file.
+func (resolver *aliasResolver) resolve(t reflect.Type, node interface{}, keys, sourceKeys []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if decodesItself(t) {
+		return
+	}
+
+	switch node := node.(type) {
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, item := range node {
+			resolver.resolve(t.Elem(), item, appendKey(keys, strconv.Itoa(i)), appendKey(sourceKeys, strconv.Itoa(i)))
+		}
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for _, key := range sortedKeys(node) {
+				resolver.resolve(t.Elem(), node[key], appendKey(keys, key), appendKey(sourceKeys, key))
+			}
+		case reflect.Struct:
+			resolver.resolveStruct(t, node, keys, sourceKeys, hclLabelCount(t))
+		case reflect.Slice, reflect.Array:
+			// labelled HCL blocks of a list
+			if resolver.format == FormatHCL {
+				resolver.resolve(t.Elem(), node, keys, sourceKeys)
+			}
+		}
+	}
+}
+
+func (resolver *aliasResolver) resolveStruct(t reflect.Type, node map[string]interface{}, keys, sourceKeys []string, labels int) {
+	if resolver.format == FormatHCL && labels > 0 {
+		for _, key := range sortedKeys(node) {
+			if body, ok := node[key].(map[string]interface{}); ok {
+				resolver.resolveStruct(t, body, appendKey(keys, key), appendKey(sourceKeys, key), labels-1)
+			}
+		}
+		return
+	}
+
+	renamed := map[string]string{}
+	for _, fieldStruct := range documentFields(t, resolver.format) {
+		name := documentKey(&fieldStruct, resolver.format)
+		for _, alias := range fieldAliases(&fieldStruct) {
+			value, ok := node[alias]
+			if !ok || alias == name {
+				continue
+			}
+
+			resolver.warn(appendKey(sourceKeys, alias), strings.Join(appendKey(keys, name), "."), fieldStruct.Tag.Get("deprecated"))
+			delete(node, alias)
+			resolver.modified = true
+			// the key takes precedence over its aliases
+			if _, ok := node[name]; !ok {
+				node[name] = value
+				renamed[name] = alias
+				resolver.renames[strings.Join(appendKey(keys, name), ".")] = appendKey(sourceKeys, alias)
+			}
+		}
+	}
+
+	for _, key := range sortedKeys(node) {
+		fieldStruct, ok := decoderField(t, key, resolver.format)
+		if !ok {
+			continue
+		}
+
+		sourceKey, isRenamed := renamed[key]
+		if !isRenamed {
+			sourceKey = key
+			if message := fieldStruct.Tag.Get("deprecated"); message != "" {
+				resolver.warn(appendKey(sourceKeys, key), "", message)
+			}
+		}
+		resolver.resolve(fieldStruct.Type, node[key], appendKey(keys, key), appendKey(sourceKeys, sourceKey))
+	}
+}
+
+// documentFields returns the fields of a struct which are read from config
+// files of the format, including those of inline structs. yaml.v2 only
+// inlines fields tagged with ,inline, encoding/json and toml promote the
+// fields of untagged embedded structs.
+func documentFields(t reflect.Type, format Format) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if isIgnoredField(&fieldStruct) {
+			continue
+		}
+
+		fieldType := fieldStruct.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if isDocumentInline(&fieldStruct, format) && fieldType.Kind() == reflect.Struct {
+			fields = append(fields, documentFields(fieldType, format)...)
+			continue
+		}
+		fields = append(fields, fieldStruct)
+	}
+	return fields
+}
+
+// isDocumentInline reports whether the decoder of the format reads the
+// fields of the field's struct from the enclosing object
+func isDocumentInline(fieldStruct *reflect.StructField, format Format) bool {
+	switch format {
+	case FormatYAML:
+		return strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") && fieldStruct.Type.Kind() == reflect.Struct
+	case FormatJSON, FormatJSONC:
+		return fieldStruct.Anonymous && tagKey(fieldStruct, "json") == ""
+	case FormatTOML:
+		return fieldStruct.Anonymous && tagKey(fieldStruct, "toml") == ""
+	}
+	return isInlineField(fieldStruct)
+}
@@ -0,0 +1,82 @@
+package configService
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type DeprecationServer struct {
+	Host string `alias:"hostname"`
+}
+
+type deprecationConfig struct {
+	DeprecationServer
+	Database struct {
+		Name string `alias:"db_name"`
+	} `yaml:",inline"`
+	Debug bool `deprecated:"use the log level instead"`
+}
+
+func TestDeprecatedAliasesOfEmbeddedStructs(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		data     string
+		host     string
+		dbName   string
+		warnings []string
+	}{
+		{"yaml embedded struct", "config.yml", "deprecationserver:\n  hostname: a\n", "a", "",
+			[]string{"config.yml:2:3: deprecationserver.hostname is deprecated, use deprecationserver.host instead"}},
+		{"yaml embedded keys aren't inline", "config.yml", "hostname: a\n", "", "", nil},
+		{"yaml inline struct", "config.yml", "db_name: b\n", "", "b",
+			[]string{"config.yml:1:1: db_name is deprecated, use name instead"}},
+		{"json promoted fields", "config.json", "{\"hostname\": \"a\"}", "a", "",
+			[]string{"config.json:1:2: hostname is deprecated, use Host instead"}},
+		{"toml promoted fields", "config.toml", "hostname = \"a\"\n", "a", "",
+			[]string{"config.toml:1:1: hostname is deprecated, use Host instead"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTestFiles(t, map[string]string{test.file: test.data})
+			var warnings []string
+			var config deprecationConfig
+			service := New(&Config{Silent: true, DeprecationHandler: func(warning DeprecationWarning) {
+				warnings = append(warnings, warning.String())
+			}})
+			if err := service.Load(&config, filepath.Join(dir, test.file)); err != nil {
+				t.Fatal(err)
+			}
+
+			if config.Host != test.host || config.Database.Name != test.dbName {
+				t.Errorf("got %+v, want host %q and database %q", config, test.host, test.dbName)
+			}
+			for i := range warnings {
+				warnings[i] = strings.TrimPrefix(warnings[i], dir+string(filepath.Separator))
+			}
+			if strings.Join(warnings, "\n") != strings.Join(test.warnings, "\n") {
+				t.Errorf("got warnings %q, want %q", warnings, test.warnings)
+			}
+		})
+	}
+}
+
+func TestDeprecationCutoff(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "debug: true\n"})
+
+	logger := &testLogger{}
+	if err := New(&Config{Logger: logger}).Load(&deprecationConfig{}, filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(logger.String(), "Warning: "+filepath.Join(dir, "config.yml")+":1:1: debug is deprecated: use the log level instead") {
+		t.Errorf("got log %q, want a deprecation warning", logger)
+	}
+
+	err := New(&Config{DeprecationCutoff: time.Now().Add(-time.Hour)}).Load(&deprecationConfig{}, filepath.Join(dir, "config.yml"))
+	if _, ok := err.(*DeprecationError); !ok {
+		t.Errorf("got %v, want a DeprecationError after the cutoff", err)
+	}
+}
@@ -0,0 +1,141 @@
+package configService
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParentDirectories is a search path which stands for the working directory
+// and its parents up to the root of the repository containing it. They are
+// searched for .{name}.yml, .{name}, {name}.yml and .{name}/config.yml,
+// closest first.
+const ParentDirectories = "{parents}"
+
+// DefaultSearchPaths are searched by Discover if no SearchPaths are set. Each
+// path is searched for config.yml inside of it and {path}.yml, with every
+// supported extension, and for the file {path} itself. Files without an
+// extension are only found if their content is in a known format.
+var DefaultSearchPaths = []string{
+	ParentDirectories,
+	"$XDG_CONFIG_HOME/{name}",
+	"~/.{name}",
+	"/etc/{name}",
+}
+
+// Discover searches the configuration files for the given name, e.g. "myapp",
+// in the search paths. They are returned with the highest precedence first,
+// like files are passed to Load.
+func (configService *ConfigService) Discover(name string) []string {
+	searchPaths := configService.Config.SearchPaths
+	if len(searchPaths) == 0 {
+		searchPaths = DefaultSearchPaths
+	}
+
+	var candidates []string
+	for _, searchPath := range searchPaths {
+		if searchPath == ParentDirectories {
+			for _, dir := range parentDirectories() {
+				candidates = append(candidates, withExtensions(filepath.Join(dir, "."+name))...)
+				candidates = append(candidates, filepath.Join(dir, "."+name))
+				candidates = append(candidates, withExtensions(filepath.Join(dir, name))...)
+				candidates = append(candidates, withExtensions(filepath.Join(dir, "."+name, "config"))...)
+			}
+			continue
+		}
+
+		dir := expandSearchPath(searchPath, name)
+		candidates = append(candidates, withExtensions(filepath.Join(dir, "config"))...)
+		candidates = append(candidates, withExtensions(dir)...)
+		candidates = append(candidates, dir)
+	}
+
+	var found []string
+	for _, candidate := range candidates {
+		if fileInfo, err := os.Stat(candidate); err == nil && fileInfo.Mode().IsRegular() && isConfigFile(candidate) {
+			found = append(found, candidate)
+			if configService.Config.Debug || configService.Config.Verbose {
+				configService.logf("Found configuration %v\n", candidate)
+			}
+		} else if configService.Config.Verbose {
+			configService.logf("Searched configuration %v\n", candidate)
+		}
+	}
+
+	if len(found) == 0 && !configService.Silent {
+		configService.logf("Failed to find configuration %v, searched %v\n", name, strings.Join(candidates, ", "))
+	}
+	return found
+}
+
+// LoadDiscovered will unmarshal configurations to struct from the files found
+// by Discover. Only the first file is loaded unless DiscoverAll is set.
+func (configService *ConfigService) LoadDiscovered(config interface{}, name string) error {
+	files := configService.Discover(name)
+	if len(files) > 1 && !configService.Config.DiscoverAll {
+		files = files[:1]
+	}
+	return configService.Load(config, files...)
+}
+
+func expandSearchPath(searchPath, name string) string {
+	searchPath = strings.Replace(searchPath, "{name}", name, -1)
+
+	home, _ := os.UserHomeDir()
+	if searchPath == "~" || strings.HasPrefix(searchPath, "~/") {
+		searchPath = home + searchPath[1:]
+	}
+
+	return os.Expand(searchPath, func(variable string) string {
+		value := os.Getenv(variable)
+		if value == "" && variable == "XDG_CONFIG_HOME" {
+			return filepath.Join(home, ".config")
+		}
+		return value
+	})
+}
+
+// parentDirectories returns the working directory and its parents up to the
+// first one containing a .git entry
+func parentDirectories() []string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dirs
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dirs
+		}
+		dir = parent
+	}
+}
+
+// isConfigFile reports whether file has a configuration extension or, if it
+// has none, whether its content is in a known format
+func isConfigFile(file string) bool {
+	if fileFormat(file) != "" {
+		return true
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	return firstContentLine(data) == "" || detectFormat(data) != ""
+}
+
+func withExtensions(file string) []string {
+	var files []string
+	for _, extension := range configExtensions() {
+		files = append(files, file+extension)
+	}
+	return files
+}
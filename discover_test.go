@@ -0,0 +1,117 @@
+package configService
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setHome points the home directory of the test at dir
+func setHome(t *testing.T, dir string) {
+	t.Helper()
+	home, hadHome := os.LookupEnv("HOME")
+	os.Setenv("HOME", dir)
+	t.Cleanup(func() {
+		if hadHome {
+			os.Setenv("HOME", home)
+		} else {
+			os.Unsetenv("HOME")
+		}
+	})
+}
+
+func TestDiscover(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"home/.myapp":              "port: 1\n",
+		"home/.other":              "\x00\x01binary",
+		"etc/myapp/config.json":    "{\"port\": 2}",
+		"etc/myapp.toml":           "port = 3\n",
+		"etc/myapp/config.unknown": "port: 4\n",
+		"xdg/myapp/config.yml":     "port: 5\n",
+	})
+	setHome(t, filepath.Join(dir, "home"))
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	defer os.Unsetenv("XDG_CONFIG_HOME")
+
+	service := New(&Config{Silent: true, SearchPaths: []string{"$XDG_CONFIG_HOME/{name}", "~/.{name}", filepath.Join(dir, "etc", "{name}")}})
+	got := service.Discover("myapp")
+	want := []string{
+		filepath.Join(dir, "xdg", "myapp", "config.yml"),
+		filepath.Join(dir, "home", ".myapp"),
+		filepath.Join(dir, "etc", "myapp", "config.json"),
+		filepath.Join(dir, "etc", "myapp.toml"),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// extensionless files are sniffed
+	if got := service.Discover("other"); len(got) != 0 {
+		t.Errorf("got %v, want binary files to be skipped", got)
+	}
+
+	logger := &testLogger{}
+	if got := New(&Config{Logger: logger, SearchPaths: []string{dir}}).Discover("missing"); len(got) != 0 || !strings.Contains(logger.String(), "Failed to find configuration missing") {
+		t.Errorf("got %v and log %q", got, logger)
+	}
+}
+
+func TestDiscoverParentDirectories(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"repo/.git/HEAD":             "",
+		"repo/.myapp":                "port: 1\n",
+		"repo/sub/myapp.yml":         "port: 2\n",
+		"repo/sub/.myapp/config.yml": "port: 3\n",
+		"myapp.yml":                  "port: 4\n",
+	})
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join(dir, "repo", "sub")); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	got := New(&Config{Silent: true, SearchPaths: []string{ParentDirectories}}).Discover("myapp")
+	// the working directory may be reached through a symlink
+	for i := range got {
+		got[i] = filepath.Base(filepath.Dir(got[i])) + "/" + filepath.Base(got[i])
+	}
+	want := []string{"sub/myapp.yml", ".myapp/config.yml", "repo/.myapp"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadDiscovered(t *testing.T) {
+	type appConfig struct {
+		Name string
+		Port int
+	}
+
+	dir := writeTestFiles(t, map[string]string{
+		"home/.myapp":      "port = 1\n",
+		"etc/myapp.yml":    "name: app\nport: 2\n",
+		"etc/myapp/readme": "not a config",
+	})
+	setHome(t, filepath.Join(dir, "home"))
+	searchPaths := []string{"~/.{name}", filepath.Join(dir, "etc", "{name}")}
+
+	var config appConfig
+	if err := New(&Config{Silent: true, SearchPaths: searchPaths}).LoadDiscovered(&config, "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "" || config.Port != 1 {
+		t.Errorf("got %+v, want the first file only", config)
+	}
+
+	config = appConfig{}
+	if err := New(&Config{Silent: true, SearchPaths: searchPaths, DiscoverAll: true}).LoadDiscovered(&config, "myapp"); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" || config.Port != 1 {
+		t.Errorf("got %+v, want the files merged", config)
+	}
+}
@@ -0,0 +1,151 @@
+package configService
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Format is the format of configuration data
+type Format string
+
+// Supported formats
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+)
+
+// fileFormat returns the format of a configuration file based on its
+// extension, or an empty format if it is unknown
+func fileFormat(file string) Format {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	var format Format
+	longest := 0
+	for _, registered := range formats {
+		for _, extension := range registered.extensions {
+			if strings.HasSuffix(file, extension) && len(extension) > longest {
+				format, longest = registered.name, len(extension)
+			}
+		}
+	}
+	return format
+}
+
+// configExtensions returns the extensions of the supported configuration
+// files
+func configExtensions() []string {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	return append([]string{}, extensions...)
+}
+
+// decodeDocument decodes configuration data into generic maps, slices and
+// values. If format is empty, it is detected and returned.
+func decodeDocument(format Format, data []byte) (interface{}, Format, error) {
+	if format == "" {
+		if format = detectFormat(data); format == "" {
+			return nil, "", errors.New("failed to decode config")
+		}
+	}
+
+	registered := lookupFormat(format)
+	if registered == nil {
+		return nil, format, fmt.Errorf("unknown format %v", format)
+	}
+
+	var document interface{}
+	if registered.decodeDocument != nil {
+		var err error
+		if document, err = registered.decodeDocument(data); err != nil {
+			return nil, format, err
+		}
+	} else {
+		var table map[string]interface{}
+		if err := registered.decoder(data, &table, false); err != nil {
+			return nil, format, err
+		}
+		document = table
+	}
+	return normalizeValue(document), format, nil
+}
+
+// encodeDocument encodes a generic document in the given format
+func encodeDocument(format Format, document interface{}) ([]byte, error) {
+	registered := lookupFormat(format)
+	if registered == nil || registered.encoder == nil {
+		return nil, fmt.Errorf("format %v can't be encoded", format)
+	}
+	return registered.encoder(document)
+}
+
+// mergeDocuments merges override into base. Maps are merged recursively, any
+// other value of override replaces the one of base.
+func mergeDocuments(base, override interface{}) interface{} {
+	baseMap, ok := base.(map[string]interface{})
+	overrideMap, ok2 := override.(map[string]interface{})
+	if !ok || !ok2 {
+		return override
+	}
+
+	for key, value := range overrideMap {
+		if existing, ok := baseMap[key]; ok {
+			baseMap[key] = mergeDocuments(existing, value)
+		} else {
+			baseMap[key] = value
+		}
+	}
+	return baseMap
+}
+
+// mergeEnvironmentSections merges the default section of a document with the
+// sections of the current environments. Documents without any of these
+// sections aren't changed, so plain override files keep working. The
+// returned locate function finds keys of the merged document in the
+// sections of the original one.
+func (configService *ConfigService) mergeEnvironmentSections(file string, document interface{}, locate func([]string) (int, int, bool)) (interface{}, func([]string) (int, int, bool), bool) {
+	sections := append([]string{"default"}, configService.GetEnvironments()...)
+	documentMap, ok := document.(map[string]interface{})
+	if !ok {
+		return document, locate, false
+	}
+
+	merged := map[string]interface{}{}
+	isSection := map[string]bool{}
+	for _, section := range sections {
+		if value, ok := documentMap[section].(map[string]interface{}); ok {
+			mergeDocuments(merged, value)
+			isSection[section] = true
+		}
+	}
+	if len(isSection) == 0 {
+		return document, locate, false
+	}
+
+	for _, key := range sortedKeys(documentMap) {
+		if isSection[key] {
+			continue
+		}
+		if _, ok := documentMap[key].(map[string]interface{}); ok {
+			// sections of other environments
+			if configService.Config.Debug || configService.Config.Verbose {
+				configService.logf("Ignoring section %v of %v\n", key, file)
+			}
+		} else if !configService.Silent {
+			line, _, _ := locate([]string{key})
+			configService.logf("Warning: %v:%v: ignoring %v outside the environment sections\n", file, line, key)
+		}
+	}
+
+	return merged, func(path []string) (int, int, bool) {
+		for i := len(sections) - 1; i >= 0; i-- {
+			if line, column, found := locate(append([]string{sections[i]}, path...)); found {
+				return line, column, true
+			}
+		}
+		return locate(path)
+	}, true
+}
@@ -0,0 +1,76 @@
+package configService
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type sectionsConfig struct {
+	Host string
+	Port int
+}
+
+func loadSections(t *testing.T, environment string, files ...string) (sectionsConfig, string, error) {
+	t.Helper()
+	contents := map[string]string{}
+	names := make([]string, len(files)/2)
+	for i := 0; i < len(files); i += 2 {
+		contents[files[i]] = files[i+1]
+	}
+	dir := writeTestFiles(t, contents)
+	for i := range names {
+		names[i] = filepath.Join(dir, files[2*i])
+	}
+
+	logger := &testLogger{}
+	var config sectionsConfig
+	err := New(&Config{Environment: environment, EnvironmentSections: true, Logger: logger}).Load(&config, names...)
+	return config, logger.String(), err
+}
+
+func TestEnvironmentSections(t *testing.T) {
+	data := "default:\n  host: localhost\n  port: 1\nproduction:\n  host: prod\nstaging:\n  port: 3\n"
+
+	config, _, err := loadSections(t, "production", "config.yml", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "prod" || config.Port != 1 {
+		t.Errorf("got %+v, want the production section merged into the default one", config)
+	}
+
+	config, _, err = loadSections(t, "development", "config.yml", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "localhost" || config.Port != 1 {
+		t.Errorf("got %+v, want the default section only", config)
+	}
+}
+
+func TestEnvironmentSectionsKeepPlainFiles(t *testing.T) {
+	// Load applies the first file last
+	config, _, err := loadSections(t, "production",
+		"override.yml", "port: 2\n",
+		"config.yml", "default:\n  host: localhost\n  port: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Host != "localhost" || config.Port != 2 {
+		t.Errorf("got %+v, want the plain override file to be loaded as it is", config)
+	}
+}
+
+func TestEnvironmentSectionsReportIgnoredKeys(t *testing.T) {
+	config, log, err := loadSections(t, "production", "config.yml", "port: 2\ndefault:\n  port: 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 1 {
+		t.Errorf("got %+v, want keys outside the sections to be ignored", config)
+	}
+	if !strings.Contains(log, "config.yml:1: ignoring port outside the environment sections") {
+		t.Errorf("got log %q, want a warning about port", log)
+	}
+}
@@ -0,0 +1,287 @@
+package configService
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+)
+
+// getDotenvFiles returns the dotenv files to load, each followed by its
+// environment variants, e.g. .env.production
+func (configService *ConfigService) getDotenvFiles() []string {
+	var files []string
+	for _, file := range configService.Config.DotenvFiles {
+		files = append(files, file)
+		for _, env := range configService.GetEnvironments() {
+			files = append(files, file+"."+env)
+		}
+	}
+	return files
+}
+
+// loadDotenv reads the dotenv files, later files override the variables set
+// by earlier ones. The modification times of the files are added to
+// modTimes, missing files are tracked with a zero time.
+func (configService *ConfigService) loadDotenv(modTimes map[string]time.Time) error {
+	configService.dotenv = map[string]string{}
+
+	for _, file := range configService.getDotenvFiles() {
+		if modTimes != nil {
+			modTimes[file] = time.Time{}
+		}
+
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if modTimes != nil {
+			modTimes[file] = fileInfo.ModTime()
+		}
+
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		if configService.Config.Debug || configService.Config.Verbose {
+			configService.logf("Loading environment variables from file '%v'...\n", file)
+		}
+
+		values, err := parseDotenv(string(data), func(name string) (string, bool) {
+			value, ok := configService.dotenv[name]
+			return value, ok
+		})
+		if err != nil {
+			return fmt.Errorf("%v:%v", file, err)
+		}
+		for name, value := range values {
+			configService.dotenv[name] = value
+		}
+	}
+	return nil
+}
+
+// lookupEnv returns the value of an environment variable. Variables of the
+// process take precedence over those of the dotenv files.
+func (configService *ConfigService) lookupEnv(name string) (string, bool) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, true
+	}
+	value, ok := configService.dotenv[name]
+	return value, ok
+}
+
+// parseDotenv parses the variables of a dotenv file. Values may be single
+// quoted, which are taken literally, or double quoted, which support escape
+// sequences. Unquoted and double quoted values can reference variables as
+// $NAME, ${NAME} or ${NAME:-default}. They are looked up in the environment
+// of the process, the variables defined before in data and with lookup.
+func parseDotenv(data string, lookup func(string) (string, bool)) (map[string]string, error) {
+	parser := &dotenvParser{data: strings.Replace(data, "\r\n", "\n", -1), line: 1, values: map[string]string{}}
+	parser.lookup = func(name string) (string, bool) {
+		if value, ok := os.LookupEnv(name); ok {
+			return value, true
+		}
+		if value, ok := parser.values[name]; ok {
+			return value, true
+		}
+		return lookup(name)
+	}
+
+	for {
+		parser.skipBlank()
+		if parser.pos >= len(parser.data) {
+			return parser.values, nil
+		}
+
+		line := parser.line
+		if err := parser.parseLine(); err != nil {
+			return nil, fmt.Errorf("%v: %v", line, err)
+		}
+	}
+}
+
+type dotenvParser struct {
+	data   string
+	pos    int
+	line   int
+	values map[string]string
+	lookup func(string) (string, bool)
+}
+
+// skipBlank skips whitespace, empty lines and comments
+func (parser *dotenvParser) skipBlank() {
+	for parser.pos < len(parser.data) {
+		switch parser.data[parser.pos] {
+		case '\n':
+			parser.line++
+			parser.pos++
+		case ' ', '\t':
+			parser.pos++
+		case '#':
+			parser.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (parser *dotenvParser) skipLine() {
+	for parser.pos < len(parser.data) && parser.data[parser.pos] != '\n' {
+		parser.pos++
+	}
+}
+
+func (parser *dotenvParser) skipSpace() {
+	for parser.pos < len(parser.data) && (parser.data[parser.pos] == ' ' || parser.data[parser.pos] == '\t') {
+		parser.pos++
+	}
+}
+
+func (parser *dotenvParser) parseLine() error {
+	name := parser.readName()
+	if name == "export" {
+		parser.skipSpace()
+		if parser.pos < len(parser.data) && parser.data[parser.pos] != '=' {
+			name = parser.readName()
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("expected variable name")
+	}
+
+	parser.skipSpace()
+	if parser.pos >= len(parser.data) || parser.data[parser.pos] != '=' {
+		return fmt.Errorf("expected '=' after %v", name)
+	}
+	parser.pos++
+	parser.skipSpace()
+
+	var value string
+	var err error
+	if parser.pos < len(parser.data) && (parser.data[parser.pos] == '\'' || parser.data[parser.pos] == '"') {
+		if value, err = parser.readQuoted(); err != nil {
+			return err
+		}
+		parser.skipSpace()
+		if parser.pos < len(parser.data) && parser.data[parser.pos] != '\n' && parser.data[parser.pos] != '#' {
+			return fmt.Errorf("unexpected characters after value of %v", name)
+		}
+		parser.skipLine()
+	} else {
+		start := parser.pos
+		parser.skipLine()
+		// a # preceded by whitespace starts a comment, e.g. A=1	# comment
+		raw := parser.data[start:parser.pos]
+		for i := 0; i < len(raw); i++ {
+			if raw[i] == '#' && (parser.data[start+i-1] == ' ' || parser.data[start+i-1] == '\t') {
+				raw = raw[:i]
+				break
+			}
+		}
+		value = parser.expand(strings.TrimSpace(raw))
+	}
+
+	parser.values[name] = value
+	return nil
+}
+
+func (parser *dotenvParser) readName() string {
+	start := parser.pos
+	for parser.pos < len(parser.data) && isDotenvNameChar(parser.data[parser.pos]) {
+		parser.pos++
+	}
+	return parser.data[start:parser.pos]
+}
+
+func isDotenvNameChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// readQuoted reads a quoted value, which may span several lines
+func (parser *dotenvParser) readQuoted() (string, error) {
+	quote := parser.data[parser.pos]
+	parser.pos++
+
+	var value strings.Builder
+	for parser.pos < len(parser.data) {
+		c := parser.data[parser.pos]
+		parser.pos++
+
+		switch {
+		case c == quote:
+			if quote == '"' {
+				return parser.expand(value.String()), nil
+			}
+			return value.String(), nil
+		case c == '\n':
+			parser.line++
+			value.WriteByte(c)
+		case c == '\\' && quote == '"' && parser.pos < len(parser.data):
+			escaped := parser.data[parser.pos]
+			parser.pos++
+			switch escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case '$':
+				// kept escaped until the value is expanded
+				value.WriteString(`\$`)
+			default:
+				value.WriteByte(escaped)
+			}
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated quoted value")
+}
+
+// expand replaces references to variables in value
+func (parser *dotenvParser) expand(value string) string {
+	var result strings.Builder
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value) && value[i+1] == '$':
+			result.WriteByte('$')
+			i++
+		case value[i] == '$' && i+1 < len(value) && value[i+1] == '{':
+			end := strings.IndexByte(value[i:], '}')
+			if end < 0 {
+				result.WriteString(value[i:])
+				return result.String()
+			}
+			reference := value[i+2 : i+end]
+			name, fallback, hasFallback := reference, "", false
+			if index := strings.Index(reference, ":-"); index >= 0 {
+				name, fallback, hasFallback = reference[:index], reference[index+2:], true
+			}
+			if resolved, _ := parser.lookup(name); resolved != "" || !hasFallback {
+				result.WriteString(resolved)
+			} else {
+				result.WriteString(fallback)
+			}
+			i += end
+		case value[i] == '$' && i+1 < len(value) && isDotenvReferenceChar(value[i+1]):
+			end := i + 1
+			for end < len(value) && isDotenvReferenceChar(value[end]) {
+				end++
+			}
+			resolved, _ := parser.lookup(value[i+1 : end])
+			result.WriteString(resolved)
+			i = end - 1
+		default:
+			result.WriteByte(value[i])
+		}
+	}
+	return result.String()
+}
+
+func isDotenvReferenceChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
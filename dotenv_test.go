@@ -0,0 +1,151 @@
+package configService
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDotenv(t *testing.T) {
+	lookup := func(name string) (string, bool) {
+		if name == "CONFIGSERVICE_TEST_OUTER" {
+			return "outer", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want map[string]string
+	}{
+		{"plain", "A=1\nB = two\n", map[string]string{"A": "1", "B": "two"}},
+		{"export", "export A=1\nexport=2\n", map[string]string{"A": "1", "export": "2"}},
+		{"comments", "# comment\n\nA=1 # trailing\nB=a#b\n", map[string]string{"A": "1", "B": "a#b"}},
+		{"comment after a tab", "A=1\t# trailing\nB=2\t\t#\n", map[string]string{"A": "1", "B": "2"}},
+		{"comment instead of a value", "A= # comment\nB=\t#\n", map[string]string{"A": "", "B": ""}},
+		{"crlf", "A=1\r\nB=2\r\n", map[string]string{"A": "1", "B": "2"}},
+		{"empty value", "A=\n", map[string]string{"A": ""}},
+		{"single quoted", `A='$B \n # x'`, map[string]string{"A": `$B \n # x`}},
+		{"double quoted escapes", `A="a\nb\tc\"d\\e"`, map[string]string{"A": "a\nb\tc\"d\\e"}},
+		{"double quoted comment", `A="1" # comment`, map[string]string{"A": "1"}},
+		{"multiline", "A=\"line 1\nline 2\"\nB=3\n", map[string]string{"A": "line 1\nline 2", "B": "3"}},
+		{"reference", "A=1\nB=$A-${A}\n", map[string]string{"A": "1", "B": "1-1"}},
+		{"escaped reference", `A="\$A"`, map[string]string{"A": "$A"}},
+		{"fallback", "A=${CONFIGSERVICE_TEST_UNSET:-default}\nB=${CONFIGSERVICE_TEST_UNSET}\n", map[string]string{"A": "default", "B": ""}},
+		{"lookup", "A=$CONFIGSERVICE_TEST_OUTER\n", map[string]string{"A": "outer"}},
+		{"unterminated reference", "A=${B\n", map[string]string{"A": "${B"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseDotenv(test.data, lookup)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseDotenvErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"missing name", "=1\n", "1: expected variable name"},
+		{"missing equals", "A=1\nB 2\n", "2: expected '=' after B"},
+		{"unterminated quote", "A=\"1\nB=2\n", "1: unterminated quoted value"},
+		{"after quote", "A='1' 2\n", "1: unexpected characters after value of A"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseDotenv(test.data, func(string) (string, bool) { return "", false })
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+type dotenvConfig struct {
+	Name string
+	Port int
+	DB   struct {
+		Host string
+	}
+}
+
+func TestLoadDotenv(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml":      "name: file\nport: 1\n",
+		".env":            "export DOTENVAPP_PORT=2\nDOTENVAPP_NAME=dotenv\nDOTENVAPP_DB_HOST=${DOTENVAPP_NAME}-host # comment\n",
+		".env.production": "DOTENVAPP_NAME=production\n",
+	})
+	setTestEnv(t, map[string]string{"DOTENVAPP_PORT": "3"})
+
+	var config dotenvConfig
+	service := New(&Config{Silent: true, ENVPrefix: "DotenvApp", Environment: "production", DotenvFiles: []string{filepath.Join(dir, ".env"), filepath.Join(dir, ".env.missing")}})
+	if err := service.Load(&config, filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the process environment takes precedence, the environment variant
+	// overrides the file and interpolation sees the variables defined before
+	want := dotenvConfig{Name: "production", Port: 3}
+	want.DB.Host = "dotenv-host"
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("got %+v, want %+v", config, want)
+	}
+	if _, ok := os.LookupEnv("DOTENVAPP_NAME"); ok {
+		t.Error("the process environment was modified")
+	}
+
+	dir = writeTestFiles(t, map[string]string{".env": "DOTENVAPP_NAME=\"unterminated\n"})
+	err := New(&Config{Silent: true, ENVPrefix: "DotenvApp", DotenvFiles: []string{filepath.Join(dir, ".env")}}).Load(&dotenvConfig{})
+	if want := filepath.Join(dir, ".env") + ":1: unterminated quoted value"; err == nil || err.Error() != want {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestReloadDotenv(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml": "name: file\n",
+		".env":       "DOTENVAPP_PORT=1\n",
+	})
+
+	reloaded := make(chan dotenvConfig, 1)
+	service := New(&Config{
+		Silent:             true,
+		ENVPrefix:          "DotenvApp",
+		DotenvFiles:        []string{filepath.Join(dir, ".env")},
+		AutoReload:         true,
+		AutoReloadInterval: 10 * time.Millisecond,
+		AutoReloadCallback: func(config interface{}) {
+			reloaded <- *config.(*dotenvConfig)
+		},
+	})
+	config := &dotenvConfig{}
+	if err := service.Load(config, filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 1 {
+		t.Fatalf("got %+v", config)
+	}
+
+	rewriteTestFile(t, filepath.Join(dir, ".env"), "DOTENVAPP_PORT=2\n")
+	select {
+	case got := <-reloaded:
+		if got.Name != "file" || got.Port != 2 {
+			t.Errorf("got %+v after changing .env", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config wasn't reloaded")
+	}
+}
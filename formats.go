@@ -0,0 +1,246 @@
+package configService
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// Decoder decodes configuration data into config. If errorOnUnmatchedKeys is
+// true, keys which don't match any field of config must be reported as error.
+type Decoder func(data []byte, config interface{}, errorOnUnmatchedKeys bool) error
+
+// Encoder encodes config as configuration data
+type Encoder func(config interface{}) ([]byte, error)
+
+// Sniffer reports whether configuration data, whose format isn't known from
+// a file extension, looks like it is in a certain format
+type Sniffer func(data []byte) bool
+
+type registeredFormat struct {
+	name       Format
+	extensions []string
+	decoder    Decoder
+	encoder    Encoder
+
+	// decodeDocument decodes data into generic maps, slices and values. If
+	// it is nil, the decoder is used with a generic map.
+	decodeDocument func(data []byte) (interface{}, error)
+
+	// locate finds keys inside the data, see locateKey
+	locate func(data []byte, path []string) (int, int, bool)
+
+	// unknownKeys finds the keys of data which don't match any field of the
+	// config type, for formats without generic documents
+	unknownKeys func(data []byte, t reflect.Type) []UnknownKey
+
+	// stringTree is set for formats whose documents hold strings only, which
+	// are shaped by the config type, see shapeStringTree
+	stringTree bool
+}
+
+type sniffer struct {
+	format  Format
+	sniffer Sniffer
+}
+
+var (
+	formatsMutex sync.RWMutex
+	formats      = map[Format]*registeredFormat{}
+	extensions   []string
+	sniffers     []sniffer
+)
+
+func init() {
+	registerFormat(&registeredFormat{
+		name:           FormatYAML,
+		extensions:     []string{".yml", ".yaml"},
+		decoder:        unmarshalYAML,
+		encoder:        yaml.Marshal,
+		decodeDocument: decodeYAMLDocument,
+		locate:         locateYAML,
+	})
+	registerFormat(&registeredFormat{
+		name:           FormatJSON,
+		extensions:     []string{".json"},
+		decoder:        unmarshalJSON,
+		encoder:        json.Marshal,
+		decodeDocument: decodeJSONDocument,
+		locate:         locateJSON,
+	})
+	registerFormat(&registeredFormat{
+		name:       FormatTOML,
+		extensions: []string{".toml"},
+		decoder:    unmarshalToml,
+		encoder:    encodeTOML,
+		locate:     locateTOML,
+	})
+
+	RegisterSniffer(string(FormatYAML), sniffYAML)
+	RegisterSniffer(string(FormatJSON), sniffJSON)
+	RegisterSniffer(string(FormatTOML), sniffTOML)
+}
+
+// RegisterFormat registers a configuration format. Files with one of the
+// extensions are decoded with decoder and saved with encoder. Both are
+// required, the encoder also writes documents changed while loading, e.g.
+// by aliases or environment sections. Registering a format again replaces
+// it.
+func RegisterFormat(name string, extensions []string, decoder Decoder, encoder Encoder) error {
+	if decoder == nil || encoder == nil {
+		return fmt.Errorf("format %v needs a decoder and an encoder", name)
+	}
+
+	registerFormat(&registeredFormat{
+		name:       Format(name),
+		extensions: extensions,
+		decoder:    decoder,
+		encoder:    encoder,
+	})
+	return nil
+}
+
+// RegisterSniffer registers a function which detects data in the format of
+// the given name if the format isn't known from a file extension. Sniffers
+// are tried in the reverse order of their registration, so the built-in
+// ones are tried last.
+func RegisterSniffer(name string, sniff Sniffer) {
+	formatsMutex.Lock()
+	defer formatsMutex.Unlock()
+
+	sniffers = append([]sniffer{{format: Format(name), sniffer: sniff}}, sniffers...)
+}
+
+func registerFormat(format *registeredFormat) {
+	formatsMutex.Lock()
+	defer formatsMutex.Unlock()
+
+	var formatExtensions []string
+	for _, extension := range format.extensions {
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+		formatExtensions = append(formatExtensions, extension)
+	}
+	format.extensions = formatExtensions
+
+	// an extension belongs to the format registered last
+	for _, other := range formats {
+		if other.name != format.name {
+			other.extensions = removeStrings(other.extensions, format.extensions)
+		}
+	}
+	extensions = append(removeStrings(extensions, format.extensions), format.extensions...)
+	formats[format.name] = format
+}
+
+func removeStrings(list, remove []string) []string {
+	var result []string
+	for _, s := range list {
+		found := false
+		for _, r := range remove {
+			found = found || s == r
+		}
+		if !found {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func lookupFormat(name Format) *registeredFormat {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	return formats[name]
+}
+
+// detectFormat returns the format of data detected by the registered
+// sniffers, or an empty format if none of them matches
+func detectFormat(data []byte) Format {
+	formatsMutex.RLock()
+	defer formatsMutex.RUnlock()
+
+	for _, sniffer := range sniffers {
+		if formats[sniffer.format] != nil && sniffer.sniffer(data) {
+			return sniffer.format
+		}
+	}
+	return ""
+}
+
+// firstContentLine returns the first line of data which is neither empty nor
+// a comment
+func firstContentLine(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+var (
+	tomlLinePattern = regexp.MustCompile(`^(\[\[?[^\[\]]+\]\]?\s*(#.*)?|[A-Za-z0-9_\-."']+\s*=.*)$`)
+	yamlLinePattern = regexp.MustCompile(`^(---|%YAML|- |-$|[^\s:#{\[][^:]*:(\s|$))`)
+)
+
+func sniffJSON(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[')
+}
+
+func sniffTOML(data []byte) bool {
+	return tomlLinePattern.MatchString(firstContentLine(data))
+}
+
+func sniffYAML(data []byte) bool {
+	return yamlLinePattern.MatchString(firstContentLine(data))
+}
+
+func unmarshalYAML(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+	if errorOnUnmatchedKeys {
+		return yaml.UnmarshalStrict(data, config)
+	}
+	return yaml.Unmarshal(data, config)
+}
+
+func encodeTOML(config interface{}) ([]byte, error) {
+	if config == nil {
+		return nil, nil
+	}
+	var buffer bytes.Buffer
+	if err := toml.NewEncoder(&buffer).Encode(config); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decodeYAMLDocument(data []byte) (interface{}, error) {
+	var document interface{}
+	err := yaml.Unmarshal(data, &document)
+	return document, err
+}
+
+// decodeJSONDocument keeps numbers as json.Number, so they are encoded again
+// without losing precision
+func decodeJSONDocument(data []byte) (interface{}, error) {
+	var document interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return document, nil
+}
@@ -0,0 +1,49 @@
+package configService
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+func TestRegisterFormat(t *testing.T) {
+	decoder := func(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+		return json.Unmarshal(data, config)
+	}
+
+	if err := RegisterFormat("nullencoder", []string{".nullencoder"}, decoder, nil); err == nil {
+		t.Error("expected an error for a nil encoder")
+	}
+	if err := RegisterFormat("nulldecoder", []string{".nulldecoder"}, nil, json.Marshal); err == nil {
+		t.Error("expected an error for a nil decoder")
+	}
+	if fileFormat("config.nullencoder") != "" || fileFormat("config.nulldecoder") != "" {
+		t.Error("rejected formats were registered")
+	}
+
+	if err := RegisterFormat("testjson", []string{"tjson"}, decoder, json.Marshal); err != nil {
+		t.Fatal(err)
+	}
+
+	// the alias is renamed in a document which is encoded again
+	type appConfig struct {
+		Name string `json:"name" alias:"title"`
+	}
+	dir := writeTestFiles(t, map[string]string{"config.tjson": `{"title": "app"}`})
+	var config appConfig
+	if err := New(&Config{Silent: true}).Load(&config, filepath.Join(dir, "config.tjson")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" {
+		t.Errorf("got %+v, want the aliased name", config)
+	}
+
+	file := filepath.Join(dir, "saved.tjson")
+	if err := New(nil).Save(&config, file); err != nil {
+		t.Fatal(err)
+	}
+	var saved appConfig
+	if err := New(nil).Load(&saved, file); err != nil || saved != config {
+		t.Errorf("got %+v and %v, want the saved config", saved, err)
+	}
+}
@@ -0,0 +1,124 @@
+package configService
+
+import (
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileSystem is where configuration files are read from
+type fileSystem interface {
+	ReadFile(name string) ([]byte, error)
+	Stat(name string) (os.FileInfo, error)
+
+	// Resolve returns the name of a file referenced relative to base
+	Resolve(base, name string) string
+
+	// Watched reports whether files can change while they are loaded
+	Watched() bool
+}
+
+type diskFileSystem struct{}
+
+func (diskFileSystem) ReadFile(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (diskFileSystem) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (diskFileSystem) Resolve(base, name string) string {
+	if !filepath.IsAbs(name) {
+		name = filepath.Join(filepath.Dir(base), name)
+	}
+	if abs, err := filepath.Abs(name); err == nil {
+		return abs
+	}
+	return name
+}
+
+func (diskFileSystem) Watched() bool {
+	return true
+}
+
+type embeddedFileSystem struct {
+	fsys fs.FS
+}
+
+func (embedded embeddedFileSystem) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(embedded.fsys, name)
+}
+
+func (embedded embeddedFileSystem) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(embedded.fsys, name)
+}
+
+func (embeddedFileSystem) Resolve(base, name string) string {
+	return path.Join(path.Dir(base), name)
+}
+
+func (embeddedFileSystem) Watched() bool {
+	return false
+}
+
+// getDefaultsFiles returns the files of DefaultsFS to load, in the order they
+// are applied. Like files on disk they are accompanied by their environment,
+// host and instance variants, or replaced by their example variant.
+func (configService *ConfigService) getDefaultsFiles(files []string) []string {
+	fsys := configService.Config.DefaultsFS
+	if fsys == nil {
+		return nil
+	}
+
+	names := configService.Config.DefaultsFiles
+	if len(names) == 0 {
+		for _, file := range files {
+			names = append(names, path.Base(filepath.ToSlash(file)))
+		}
+	}
+
+	var expanded []string
+	for _, name := range names {
+		if strings.ContainsAny(name, "*?[") {
+			matches, _ := fs.Glob(fsys, name)
+			matches = configService.removeVariantFiles(matches)
+			sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+			expanded = append(expanded, matches...)
+		} else {
+			expanded = append(expanded, name)
+		}
+	}
+
+	isFile := func(name string) bool {
+		fileInfo, err := fs.Stat(fsys, name)
+		return err == nil && fileInfo.Mode().IsRegular()
+	}
+
+	var result []string
+	for i := len(expanded) - 1; i >= 0; i-- {
+		name := expanded[i]
+		foundFile := false
+
+		if isFile(name) {
+			foundFile = true
+			result = append(result, name)
+		}
+
+		for _, suffix := range configService.getOverrideSuffixes() {
+			if variant := getFileNameWithENVPrefix(name, suffix); isFile(variant) {
+				foundFile = true
+				result = append(result, variant)
+			}
+		}
+
+		if example := getFileNameWithENVPrefix(name, "example"); !foundFile && isFile(example) {
+			result = append(result, example)
+		}
+	}
+	return result
+}
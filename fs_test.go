@@ -0,0 +1,92 @@
+package configService
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type defaultsConfig struct {
+	Name  string
+	Port  int
+	Mode  string
+	Hosts []string
+}
+
+func TestDefaultsFS(t *testing.T) {
+	defaults := fstest.MapFS{
+		"config.yml":           {Data: []byte("name: default\nport: 1\nmode: default\nhosts: [a]\n")},
+		"config.test.yml":      {Data: []byte("mode: test\n")},
+		"config.prod.yml":      {Data: []byte("mode: prod\n")},
+		"other.example.yml":    {Data: []byte("name: example\n")},
+		"conf.d/10-a.yml":      {Data: []byte("port: 10\n")},
+		"conf.d/20-b.yml":      {Data: []byte("port: 20\n")},
+		"conf.d/20-b.test.yml": {Data: []byte("name: glob variant\n")},
+		"include.yml":          {Data: []byte("$include: shared/name.yml\n")},
+		"shared/name.yml":      {Data: []byte("name: included\n")},
+	}
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml": "port: 2\n",
+		"other.yml":  "port: 3\n",
+	})
+
+	tests := []struct {
+		name     string
+		files    []string
+		defaults []string
+		want     defaultsConfig
+	}{
+		{"layered below the file with its environment variant", []string{"config.yml"}, nil,
+			defaultsConfig{Name: "default", Port: 2, Mode: "test", Hosts: []string{"a"}}},
+		{"example variant", []string{"other.yml"}, nil,
+			defaultsConfig{Name: "example", Port: 3}},
+		{"missing defaults", []string{"missing.yml"}, nil,
+			defaultsConfig{}},
+		{"explicit names", []string{"other.yml"}, []string{"config.yml"},
+			defaultsConfig{Name: "default", Port: 3, Mode: "test", Hosts: []string{"a"}}},
+		{"glob in lexical order", nil, []string{"conf.d/*.yml"},
+			defaultsConfig{Name: "glob variant", Port: 20}},
+		{"includes resolved inside the file system", nil, []string{"include.yml"},
+			defaultsConfig{Name: "included"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var files []string
+			for _, file := range test.files {
+				files = append(files, filepath.Join(dir, file))
+			}
+			var config defaultsConfig
+			service := New(&Config{Silent: true, Environment: "test", DefaultsFS: defaults, DefaultsFiles: test.defaults})
+			if err := service.Load(&config, files...); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.want) {
+				t.Errorf("got %+v, want %+v", config, test.want)
+			}
+		})
+	}
+}
+
+func TestDefaultsFSLoadBytes(t *testing.T) {
+	defaults := fstest.MapFS{
+		"defaults.json": {Data: []byte(`{"Name": "default", "Port": 1}`)},
+		"broken.yml":    {Data: []byte("port: [\n")},
+	}
+
+	var config defaultsConfig
+	service := New(&Config{Silent: true, DefaultsFS: defaults, DefaultsFiles: []string{"defaults.json"}})
+	if err := service.LoadBytes(&config, []byte("port: 2\n"), FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "default" || config.Port != 2 {
+		t.Errorf("got %+v, want the data layered over the defaults", config)
+	}
+
+	err := New(&Config{Silent: true, DefaultsFS: defaults, DefaultsFiles: []string{"broken.yml"}}).LoadBytes(&defaultsConfig{}, nil, FormatYAML)
+	if err == nil || !strings.Contains(err.Error(), "broken.yml") {
+		t.Errorf("got %v, want an error naming the default file", err)
+	}
+}
@@ -1,8 +1,11 @@
 module github.com/JojiiOfficial/configService
 
-go 1.13
+go 1.16
 
 require (
 	github.com/BurntSushi/toml v0.3.1
+	github.com/hashicorp/hcl/v2 v2.12.0
+	github.com/zclconf/go-cty v1.8.0
 	gopkg.in/yaml.v2 v2.2.8
+	gopkg.in/yaml.v3 v3.0.1
 )
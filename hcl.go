@@ -0,0 +1,766 @@
+package configService
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FormatHCL is the format of HCL files. Blocks map to nested structs and
+// maps. Labelled blocks map to maps keyed by their labels, or to slices of
+// structs whose fields tagged with hcl:"name,label" receive the labels.
+const FormatHCL Format = "hcl"
+
+func init() {
+	registerFormat(&registeredFormat{
+		name:           FormatHCL,
+		extensions:     []string{".hcl"},
+		decoder:        unmarshalHCL,
+		encoder:        marshalHCL,
+		decodeDocument: decodeHCLDocument,
+		locate:         locateHCL,
+	})
+}
+
+// parseHCL parses HCL native syntax. Errors are returned as hcl.Diagnostics,
+// which hold the source ranges of the problems.
+func parseHCL(data []byte) (*hclsyntax.Body, error) {
+	file, diagnostics := hclsyntax.ParseConfig(data, "", hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	return file.Body.(*hclsyntax.Body), nil
+}
+
+func unmarshalHCL(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+	body, err := parseHCL(data)
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(config)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("config %v should be a pointer", config)
+	}
+
+	decoder := &hclDecoder{strict: errorOnUnmatchedKeys}
+	decoder.decodeBody(body, value.Elem(), nil)
+	if decoder.diagnostics.HasErrors() {
+		return decoder.diagnostics
+	}
+	return nil
+}
+
+func decodeHCLDocument(data []byte) (interface{}, error) {
+	body, err := parseHCL(data)
+	if err != nil {
+		return nil, err
+	}
+	document, diagnostics := hclBodyDocument(body)
+	if diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	return document, nil
+}
+
+// hclDecoder decodes HCL bodies into config structs, collecting problems
+// as diagnostics
+type hclDecoder struct {
+	strict      bool
+	diagnostics hcl.Diagnostics
+}
+
+func (decoder *hclDecoder) addError(subject hcl.Range, summary, detail string) {
+	decoder.diagnostics = append(decoder.diagnostics, &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  &subject,
+	})
+}
+
+// decodeBody decodes the attributes and blocks of body into a struct or map
+func (decoder *hclDecoder) decodeBody(body *hclsyntax.Body, target reflect.Value, keys []string) {
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	switch target.Kind() {
+	case reflect.Struct, reflect.Map:
+	case reflect.Interface:
+		if target.NumMethod() == 0 {
+			document, diagnostics := hclBodyDocument(body)
+			decoder.diagnostics = append(decoder.diagnostics, diagnostics...)
+			target.Set(reflect.ValueOf(document))
+			return
+		}
+		fallthrough
+	default:
+		decoder.addError(body.SrcRange, "Unsupported block", fmt.Sprintf("%v can't be decoded from a block.", target.Type()))
+		return
+	}
+
+	for _, attribute := range sortedHCLAttributes(body) {
+		field, commit, ok := decoder.member(target, attribute.Name, attribute.NameRange)
+		if !ok {
+			continue
+		}
+
+		value, diagnostics := attribute.Expr.Value(nil)
+		decoder.diagnostics = append(decoder.diagnostics, diagnostics...)
+		if diagnostics.HasErrors() {
+			continue
+		}
+		node, err := ctyToGo(value)
+		if err != nil {
+			decoder.addError(attribute.Expr.Range(), "Invalid value", err.Error())
+			continue
+		}
+
+		var unmatched []string
+		if err := decodeTreeValue(node, field, appendKey(keys, attribute.Name), &unmatched); err != nil {
+			decoder.addError(attribute.Expr.Range(), "Invalid value", err.Error())
+			continue
+		}
+		if len(unmatched) > 0 && decoder.strict {
+			decoder.addError(attribute.Expr.Range(), "Unsupported argument", fmt.Sprintf("The keys %v don't match any field.", unmatched))
+		}
+		commit()
+	}
+
+	// repeated blocks replace lists set by earlier files
+	replaced := map[string]bool{}
+	for _, block := range body.Blocks {
+		field, commit, ok := decoder.member(target, block.Type, block.TypeRange)
+		if !ok {
+			continue
+		}
+		if !replaced[block.Type] && field.Kind() == reflect.Slice {
+			field.Set(reflect.MakeSlice(field.Type(), 0, 0))
+		}
+		replaced[block.Type] = true
+
+		decoder.decodeBlock(block, block.Labels, block.LabelRanges, field, appendKey(keys, block.Type))
+		commit()
+	}
+}
+
+// member returns the field or map entry of target named key. The value of
+// a map entry is stored by calling commit.
+func (decoder *hclDecoder) member(target reflect.Value, key string, subject hcl.Range) (reflect.Value, func(), bool) {
+	if target.Kind() == reflect.Struct {
+		field, ok := structField(target, key, true)
+		if !ok {
+			if decoder.strict {
+				decoder.addError(subject, "Unsupported argument", fmt.Sprintf("An argument or block named %q is not expected here.", key))
+			}
+			return reflect.Value{}, nil, false
+		}
+		return field, func() {}, true
+	}
+
+	mapKey, err := mapKey(target.Type(), key)
+	if err != nil {
+		decoder.addError(subject, "Invalid key", err.Error())
+		return reflect.Value{}, nil, false
+	}
+	if target.IsNil() {
+		target.Set(reflect.MakeMap(target.Type()))
+	}
+	elem := reflect.New(target.Type().Elem()).Elem()
+	if existing := target.MapIndex(mapKey); existing.IsValid() {
+		elem.Set(existing)
+	}
+	return elem, func() { target.SetMapIndex(mapKey, elem) }, true
+}
+
+// decodeBlock decodes a block whose remaining labels are labels into target
+func (decoder *hclDecoder) decodeBlock(block *hclsyntax.Block, labels []string, labelRanges []hcl.Range, target reflect.Value, keys []string) {
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	switch {
+	case target.Kind() == reflect.Slice && target.Type().Elem().Kind() != reflect.Uint8:
+		elem := reflect.New(target.Type().Elem()).Elem()
+		decoder.decodeBlock(block, labels, labelRanges, elem, appendKey(keys, strconv.Itoa(target.Len())))
+		target.Set(reflect.Append(target, elem))
+	case target.Kind() == reflect.Map && len(labels) > 0:
+		elem, commit, ok := decoder.member(target, labels[0], labelRanges[0])
+		if ok {
+			decoder.decodeBlock(block, labels[1:], labelRanges[1:], elem, appendKey(keys, labels[0]))
+			commit()
+		}
+	case target.Kind() == reflect.Struct && len(labels) > 0 && target.Type() != timeType:
+		labelFields := hclLabelFields(target)
+		if len(labelFields) == 0 {
+			// labels address nested fields, like nested blocks
+			if field, commit, ok := decoder.member(target, labels[0], labelRanges[0]); ok {
+				decoder.decodeBlock(block, labels[1:], labelRanges[1:], field, appendKey(keys, labels[0]))
+				commit()
+			}
+			return
+		}
+		if len(labelFields) != len(labels) {
+			decoder.addError(block.TypeRange, "Wrong number of labels", fmt.Sprintf("A %q block needs %v labels, but has %v.", block.Type, len(labelFields), len(labels)))
+			return
+		}
+		for i, field := range labelFields {
+			if err := setFieldFromString(field, labels[i]); err != nil {
+				decoder.addError(labelRanges[i], "Invalid label", err.Error())
+			}
+		}
+		decoder.decodeBody(block.Body, target, keys)
+	case target.Kind() == reflect.Interface && target.NumMethod() == 0:
+		document, diagnostics := hclBodyDocument(block.Body)
+		decoder.diagnostics = append(decoder.diagnostics, diagnostics...)
+		var node interface{} = document
+		for i := len(labels) - 1; i >= 0; i-- {
+			node = map[string]interface{}{labels[i]: node}
+		}
+		target.Set(reflect.ValueOf(node))
+	case target.Kind() == reflect.Struct || target.Kind() == reflect.Map:
+		decoder.decodeBody(block.Body, target, keys)
+	default:
+		decoder.addError(block.TypeRange, "Unsupported block type", fmt.Sprintf("%v is a %v and can't be set by a block.", strings.Join(keys, "."), target.Type()))
+	}
+}
+
+// hclLabelFields returns the fields of a struct tagged as block labels
+func hclLabelFields(value reflect.Value) []reflect.Value {
+	var fields []reflect.Value
+	for i := 0; i < value.NumField(); i++ {
+		fieldStruct := value.Type().Field(i)
+		if isHCLLabelField(&fieldStruct) {
+			fields = append(fields, value.Field(i))
+		}
+	}
+	return fields
+}
+
+func isHCLLabelField(fieldStruct *reflect.StructField) bool {
+	return fieldStruct.PkgPath == "" && strings.Contains(fieldStruct.Tag.Get("hcl"), ",label")
+}
+
+func sortedHCLAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	attributes := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attribute := range body.Attributes {
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		return attributes[i].SrcRange.Start.Byte < attributes[j].SrcRange.Start.Byte
+	})
+	return attributes
+}
+
+// hclBodyDocument converts a body into a generic document. Blocks become
+// maps, several unlabelled blocks of the same type a list of maps and
+// labelled blocks maps keyed by their labels.
+func hclBodyDocument(body *hclsyntax.Body) (map[string]interface{}, hcl.Diagnostics) {
+	var diagnostics hcl.Diagnostics
+	document := map[string]interface{}{}
+
+	for name, attribute := range body.Attributes {
+		value, valueDiagnostics := attribute.Expr.Value(nil)
+		diagnostics = append(diagnostics, valueDiagnostics...)
+		node, err := ctyToGo(value)
+		if err != nil {
+			diagnostics = append(diagnostics, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "Invalid value", Detail: err.Error(), Subject: attribute.Expr.Range().Ptr()})
+			continue
+		}
+		document[name] = node
+	}
+
+	unlabelled := map[string]int{}
+	for _, block := range body.Blocks {
+		if len(block.Labels) == 0 {
+			unlabelled[block.Type]++
+		}
+	}
+
+	for _, block := range body.Blocks {
+		node, blockDiagnostics := hclBodyDocument(block.Body)
+		diagnostics = append(diagnostics, blockDiagnostics...)
+
+		switch {
+		case len(block.Labels) > 0:
+			var labelled interface{} = node
+			for i := len(block.Labels) - 1; i >= 0; i-- {
+				labelled = map[string]interface{}{block.Labels[i]: labelled}
+			}
+			document[block.Type] = mergeDocuments(document[block.Type], labelled)
+		case unlabelled[block.Type] > 1:
+			list, _ := document[block.Type].([]interface{})
+			document[block.Type] = append(list, node)
+		default:
+			document[block.Type] = node
+		}
+	}
+	return document, diagnostics
+}
+
+// ctyToGo converts a cty value into generic maps, slices and values
+func ctyToGo(value cty.Value) (interface{}, error) {
+	if value.IsNull() {
+		return nil, nil
+	}
+	if !value.IsWhollyKnown() {
+		return nil, fmt.Errorf("value isn't known")
+	}
+
+	valueType := value.Type()
+	switch {
+	case valueType == cty.String:
+		return value.AsString(), nil
+	case valueType == cty.Bool:
+		return value.True(), nil
+	case valueType == cty.Number:
+		number := value.AsBigFloat()
+		if integer, accuracy := number.Int64(); accuracy == big.Exact {
+			return integer, nil
+		}
+		float, _ := number.Float64()
+		return float, nil
+	case valueType.IsListType() || valueType.IsTupleType() || valueType.IsSetType():
+		list := []interface{}{}
+		for iterator := value.ElementIterator(); iterator.Next(); {
+			_, elem := iterator.Element()
+			item, err := ctyToGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case valueType.IsMapType() || valueType.IsObjectType():
+		object := map[string]interface{}{}
+		for iterator := value.ElementIterator(); iterator.Next(); {
+			key, elem := iterator.Element()
+			item, err := ctyToGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			object[key.AsString()] = item
+		}
+		return object, nil
+	}
+	return nil, fmt.Errorf("unsupported value of type %v", valueType.FriendlyName())
+}
+
+// locateHCL finds key paths in HCL data, see locateKey
+func locateHCL(data []byte, path []string) (int, int, bool) {
+	body, err := parseHCL(data)
+	if err != nil {
+		return 0, 0, false
+	}
+	position, depth := locateHCLBody(body, path)
+	return position.Line, position.Column, depth == len(path)
+}
+
+// locateHCLBody returns the position of the longest prefix of path found in
+// body and its length
+func locateHCLBody(body *hclsyntax.Body, path []string) (hcl.Pos, int) {
+	if len(path) == 0 {
+		return body.SrcRange.Start, 0
+	}
+
+	if attribute, ok := body.Attributes[path[0]]; ok {
+		position, depth := locateHCLExpression(attribute.Expr, path[1:])
+		return position, depth + 1
+	}
+
+	var blocks []*hclsyntax.Block
+	for _, block := range body.Blocks {
+		if block.Type == path[0] {
+			blocks = append(blocks, block)
+		}
+	}
+	if len(blocks) == 0 {
+		return hcl.Pos{}, 0
+	}
+
+	rest := path[1:]
+	for _, block := range blocks {
+		if len(block.Labels) > 0 && len(block.Labels) <= len(rest) && commonPrefixLength(block.Labels, rest) == len(block.Labels) {
+			position, depth := locateHCLBody(block.Body, rest[len(block.Labels):])
+			if depth == 0 {
+				position = block.LabelRanges[len(block.Labels)-1].Start
+			}
+			return position, depth + len(block.Labels) + 1
+		}
+	}
+
+	block := blocks[0]
+	if index, err := strconv.Atoi(firstKey(rest)); err == nil && len(blocks) > 1 && index >= 0 && index < len(blocks) {
+		block, rest = blocks[index], rest[1:]
+		position, depth := locateHCLBody(block.Body, rest)
+		if depth == 0 {
+			position = block.TypeRange.Start
+		}
+		return position, depth + 2
+	}
+
+	position, depth := locateHCLBody(block.Body, rest)
+	if depth == 0 {
+		position = block.TypeRange.Start
+	}
+	return position, depth + 1
+}
+
+func locateHCLExpression(expression hclsyntax.Expression, path []string) (hcl.Pos, int) {
+	if len(path) > 0 {
+		switch expression := expression.(type) {
+		case *hclsyntax.ObjectConsExpr:
+			for _, item := range expression.Items {
+				if key, diagnostics := item.KeyExpr.Value(nil); !diagnostics.HasErrors() && key.Type() == cty.String && key.AsString() == path[0] {
+					position, depth := locateHCLExpression(item.ValueExpr, path[1:])
+					return position, depth + 1
+				}
+			}
+		case *hclsyntax.TupleConsExpr:
+			if index, err := strconv.Atoi(path[0]); err == nil && index >= 0 && index < len(expression.Exprs) {
+				position, depth := locateHCLExpression(expression.Exprs[index], path[1:])
+				return position, depth + 1
+			}
+		}
+	}
+	return expression.Range().Start, 0
+}
+
+func firstKey(path []string) string {
+	if len(path) == 0 {
+		return ""
+	}
+	return path[0]
+}
+
+// marshalHCL encodes config as HCL file. Structs and maps of blocks are
+// written as blocks, lists of structs as repeated blocks and everything else
+// as attributes.
+func marshalHCL(config interface{}) ([]byte, error) {
+	value := indirectValue(reflect.ValueOf(config))
+	if !value.IsValid() {
+		return nil, nil
+	}
+	if value.Kind() != reflect.Struct && value.Kind() != reflect.Map {
+		return nil, fmt.Errorf("hcl: %v can't be encoded as body", value.Type())
+	}
+
+	var buffer bytes.Buffer
+	if err := writeHCLBody(&buffer, value, 0); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// indirectValue dereferences pointers and interfaces, returning an invalid
+// value for nil
+func indirectValue(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+type hclMember struct {
+	name  string
+	value reflect.Value
+}
+
+// hclMembers returns the fields of a struct, except for labels, or the
+// entries of a map sorted by key
+func hclMembers(value reflect.Value) []hclMember {
+	var members []hclMember
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			fieldStruct := value.Type().Field(i)
+			if isIgnoredField(&fieldStruct) || isHCLLabelField(&fieldStruct) {
+				continue
+			}
+			if isInlineField(&fieldStruct) {
+				if field := indirectValue(value.Field(i)); field.IsValid() {
+					members = append(members, hclMembers(field)...)
+				}
+				continue
+			}
+			members = append(members, hclMember{configKey(&fieldStruct), value.Field(i)})
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			members = append(members, hclMember{fmt.Sprint(key.Interface()), value.MapIndex(key)})
+		}
+	}
+	return members
+}
+
+// isHCLObject reports whether value is a struct or map
+func isHCLObject(value reflect.Value) bool {
+	value = indirectValue(value)
+	return value.IsValid() && (value.Kind() == reflect.Map || (value.Kind() == reflect.Struct && value.Type() != timeType))
+}
+
+// isHCLBody reports whether the members of an object can be written as the
+// attributes and blocks of a body, whose names have to be identifiers. Maps
+// with other keys are written as object expressions.
+func isHCLBody(value reflect.Value) bool {
+	value = indirectValue(value)
+	if !isHCLObject(value) {
+		return false
+	}
+	if value.Kind() == reflect.Struct {
+		return true
+	}
+	for _, member := range hclMembers(value) {
+		if !hclsyntax.ValidIdentifier(member.name) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHCLBlock reports whether value is written as block. Maps are written as
+// attributes unless they contain objects.
+func isHCLBlock(value reflect.Value) bool {
+	value = indirectValue(value)
+	if !isHCLBody(value) {
+		return false
+	}
+	if value.Kind() == reflect.Struct {
+		return true
+	}
+	for _, member := range hclMembers(value) {
+		if isHCLObject(member.value) || isHCLBlockList(member.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHCLBlockList reports whether value is a list of objects, written as
+// repeated blocks
+func isHCLBlockList(value reflect.Value) bool {
+	value = indirectValue(value)
+	if !value.IsValid() || (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) || value.Len() == 0 {
+		return false
+	}
+	for i := 0; i < value.Len(); i++ {
+		if !isHCLObject(value.Index(i)) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHCLLabelledBlocks reports whether value is a map of objects, written as
+// one labelled block per entry. Entries which can't be written as body are
+// maps of objects themselves, whose keys become further labels.
+func isHCLLabelledBlocks(value reflect.Value) bool {
+	value = indirectValue(value)
+	if !value.IsValid() || value.Kind() != reflect.Map || value.Len() == 0 {
+		return false
+	}
+	for _, member := range hclMembers(value) {
+		if !isHCLBody(member.value) && !isHCLLabelledBlocks(member.value) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeHCLBody(buffer *bytes.Buffer, value reflect.Value, indent int) error {
+	prefix := strings.Repeat("  ", indent)
+	members := hclMembers(value)
+
+	for _, member := range members {
+		if !hclsyntax.ValidIdentifier(member.name) {
+			return fmt.Errorf("hcl: %q can't be written as the name of an argument or block", member.name)
+		}
+	}
+
+	for _, member := range members {
+		memberValue := indirectValue(member.value)
+		if !memberValue.IsValid() || isHCLBlock(memberValue) || isHCLLabelledBlocks(memberValue) || isHCLBlockList(memberValue) {
+			continue
+		}
+		buffer.WriteString(prefix + member.name + " = ")
+		writeHCLExpression(buffer, memberValue, indent)
+		buffer.WriteString("\n")
+	}
+
+	for _, member := range members {
+		memberValue := indirectValue(member.value)
+		var err error
+		switch {
+		case isHCLLabelledBlocks(memberValue):
+			err = writeHCLLabelledBlocks(buffer, member.name, nil, memberValue, indent)
+		case isHCLBlock(memberValue):
+			err = writeHCLBlock(buffer, member.name, nil, memberValue, indent)
+		case isHCLBlockList(memberValue):
+			for i := 0; i < memberValue.Len() && err == nil; i++ {
+				err = writeHCLBlock(buffer, member.name, nil, indirectValue(memberValue.Index(i)), indent)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHCLLabelledBlocks writes a block per entry of a map, labelled with
+// the keys of the entry and its parents
+func writeHCLLabelledBlocks(buffer *bytes.Buffer, name string, labels []string, value reflect.Value, indent int) error {
+	for _, entry := range hclMembers(value) {
+		entryLabels := append(append([]string{}, labels...), entry.name)
+		entryValue := indirectValue(entry.value)
+
+		var err error
+		if isHCLBody(entryValue) {
+			err = writeHCLBlock(buffer, name, entryLabels, entryValue, indent)
+		} else {
+			err = writeHCLLabelledBlocks(buffer, name, entryLabels, entryValue, indent)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHCLBlock(buffer *bytes.Buffer, name string, labels []string, value reflect.Value, indent int) error {
+	if value.Kind() == reflect.Struct {
+		for _, field := range hclLabelFields(value) {
+			labels = append(labels, fmt.Sprint(field.Interface()))
+		}
+	}
+
+	prefix := strings.Repeat("  ", indent)
+	buffer.WriteString(prefix + name)
+	for _, label := range labels {
+		buffer.WriteString(" " + quoteHCL(label))
+	}
+	buffer.WriteString(" {\n")
+	if err := writeHCLBody(buffer, value, indent+1); err != nil {
+		return err
+	}
+	buffer.WriteString(prefix + "}\n")
+	return nil
+}
+
+func writeHCLExpression(buffer *bytes.Buffer, value reflect.Value, indent int) {
+	value = indirectValue(value)
+	if !value.IsValid() {
+		buffer.WriteString("null")
+		return
+	}
+
+	switch value.Type() {
+	case timeType:
+		buffer.WriteString(quoteHCL(value.Interface().(time.Time).Format(time.RFC3339Nano)))
+		return
+	case durationType:
+		buffer.WriteString(quoteHCL(value.Interface().(time.Duration).String()))
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		buffer.WriteString(quoteHCL(value.String()))
+	case reflect.Bool:
+		buffer.WriteString(strconv.FormatBool(value.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		buffer.WriteString(strconv.FormatInt(value.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		buffer.WriteString(strconv.FormatUint(value.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		buffer.WriteString(strconv.FormatFloat(value.Float(), 'g', -1, 64))
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			buffer.WriteString(quoteHCL(string(value.Bytes())))
+			return
+		}
+		buffer.WriteString("[")
+		for i := 0; i < value.Len(); i++ {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+			writeHCLExpression(buffer, value.Index(i), indent)
+		}
+		buffer.WriteString("]")
+	case reflect.Struct, reflect.Map:
+		members := hclMembers(value)
+		if len(members) == 0 {
+			buffer.WriteString("{}")
+			return
+		}
+		prefix := strings.Repeat("  ", indent+1)
+		buffer.WriteString("{\n")
+		for _, member := range members {
+			buffer.WriteString(prefix + hclName(member.name) + " = ")
+			writeHCLExpression(buffer, member.value, indent+1)
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString(strings.Repeat("  ", indent) + "}")
+	default:
+		buffer.WriteString(quoteHCL(fmt.Sprint(value.Interface())))
+	}
+}
+
+// hclName quotes keys of object expressions which aren't valid identifiers
+func hclName(name string) string {
+	if hclsyntax.ValidIdentifier(name) {
+		return name
+	}
+	return quoteHCL(name)
+}
+
+// quoteHCL quotes a string, escaping template sequences
+func quoteHCL(s string) string {
+	var result strings.Builder
+	result.WriteByte('"')
+	for i, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			result.WriteRune('\\')
+			result.WriteRune(c)
+		case c == '\n':
+			result.WriteString(`\n`)
+		case c == '\r':
+			result.WriteString(`\r`)
+		case c == '\t':
+			result.WriteString(`\t`)
+		case c < 0x20:
+			fmt.Fprintf(&result, `\u%04x`, c)
+		case (c == '$' || c == '%') && strings.HasPrefix(s[i+1:], "{"):
+			result.WriteRune(c)
+			result.WriteRune(c)
+		default:
+			result.WriteRune(c)
+		}
+	}
+	result.WriteByte('"')
+	return result.String()
+}
@@ -0,0 +1,271 @@
+package configService
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type hclTestConfig struct {
+	Name     string
+	Port     int
+	Timeout  time.Duration
+	Tags     []string
+	Labels   map[string]string
+	Database struct {
+		Host string
+		User string `hcl:"username"`
+	}
+	Services []struct {
+		Name    string `hcl:"name,label"`
+		Command string
+	} `hcl:"service"`
+	Backends map[string]struct {
+		Address string
+	} `hcl:"backend"`
+	Routes map[string]map[string]struct {
+		Target string
+	} `hcl:"route"`
+	Listeners []struct {
+		Port int
+	} `hcl:"listener"`
+}
+
+func TestUnmarshalHCL(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		check func(*hclTestConfig) bool
+	}{
+		{"attributes", "name = \"app\"\nport = 80\ntimeout = \"5s\"\n", func(config *hclTestConfig) bool {
+			return config.Name == "app" && config.Port == 80 && config.Timeout == 5*time.Second
+		}},
+		{"list and map", "tags = [\"a\", \"b\"]\nlabels = { env = \"prod\", \"team-name\" = \"x\" }\n", func(config *hclTestConfig) bool {
+			return reflect.DeepEqual(config.Tags, []string{"a", "b"}) && reflect.DeepEqual(config.Labels, map[string]string{"env": "prod", "team-name": "x"})
+		}},
+		{"heredoc", "name = <<EOT\nline 1\nline 2\nEOT\n", func(config *hclTestConfig) bool {
+			return config.Name == "line 1\nline 2\n"
+		}},
+		{"comments", "# comment\n// comment\n/* block */ port = 1 # trailing\n", func(config *hclTestConfig) bool {
+			return config.Port == 1
+		}},
+		{"block", "database {\n  host = \"h\"\n  username = \"u\"\n}\n", func(config *hclTestConfig) bool {
+			return config.Database.Host == "h" && config.Database.User == "u"
+		}},
+		{"labelled blocks into label fields", "service \"web\" {\n  command = \"serve\"\n}\nservice \"worker\" {\n  command = \"work\"\n}\n", func(config *hclTestConfig) bool {
+			return len(config.Services) == 2 && config.Services[0].Name == "web" && config.Services[0].Command == "serve" && config.Services[1].Name == "worker"
+		}},
+		{"labelled blocks into map", "backend \"a\" {\n  address = \"1\"\n}\nbackend \"b\" {\n  address = \"2\"\n}\n", func(config *hclTestConfig) bool {
+			return len(config.Backends) == 2 && config.Backends["a"].Address == "1" && config.Backends["b"].Address == "2"
+		}},
+		{"two labels into nested maps", "route \"GET\" \"/\" {\n  target = \"index\"\n}\n", func(config *hclTestConfig) bool {
+			return config.Routes["GET"]["/"].Target == "index"
+		}},
+		{"repeated blocks into list", "listener {\n  port = 1\n}\nlistener {\n  port = 2\n}\n", func(config *hclTestConfig) bool {
+			return len(config.Listeners) == 2 && config.Listeners[0].Port == 1 && config.Listeners[1].Port == 2
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &hclTestConfig{}
+			if err := unmarshalHCL([]byte(test.data), config, true); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !test.check(config) {
+				t.Errorf("unexpected config %+v", config)
+			}
+		})
+	}
+}
+
+func TestUnmarshalHCLErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		strict bool
+		line   int
+	}{
+		{"syntax", "port = \n", false, 1},
+		{"unclosed block", "database {\n  host = \"h\"\n", false, 1},
+		{"type", "name = \"app\"\nport = \"abc\"\n", false, 2},
+		{"labels", "service \"web\" \"extra\" {\n}\n", false, 1},
+		{"unknown argument", "name = \"app\"\nnmae = \"x\"\n", true, 2},
+		{"unknown argument ignored", "nmae = \"x\"\n", false, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := unmarshalHCL([]byte(test.data), &hclTestConfig{}, test.strict)
+			if test.line == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			if line, _, _ := errorPosition(FormatHCL, []byte(test.data), err); line != test.line {
+				t.Errorf("got error %v at line %v, want line %v", err, line, test.line)
+			}
+		})
+	}
+}
+
+func TestDecodeHCLDocument(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]interface{}
+	}{
+		{"values", "a = 1\nb = 1.5\nc = true\nd = null\ne = [1, \"x\"]\n", map[string]interface{}{"a": int64(1), "b": 1.5, "c": true, "d": nil, "e": []interface{}{int64(1), "x"}}},
+		{"block", "db {\n  host = \"h\"\n}\n", map[string]interface{}{"db": map[string]interface{}{"host": "h"}}},
+		{"repeated blocks", "l {\n  p = 1\n}\nl {\n  p = 2\n}\n", map[string]interface{}{"l": []interface{}{map[string]interface{}{"p": int64(1)}, map[string]interface{}{"p": int64(2)}}}},
+		{"labelled blocks", "s \"a\" \"x\" {\n  p = 1\n}\ns \"a\" \"y\" {\n  p = 2\n}\n", map[string]interface{}{"s": map[string]interface{}{"a": map[string]interface{}{"x": map[string]interface{}{"p": int64(1)}, "y": map[string]interface{}{"p": int64(2)}}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := decodeHCLDocument([]byte(test.data))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLocateHCL(t *testing.T) {
+	data := []byte("name = \"app\"\nlabels = {\n  env = \"prod\"\n}\nservice \"web\" {\n  command = \"serve\"\n}\nlistener {\n  port = 1\n}\nlistener {\n  port = 2\n}\n")
+	tests := []struct {
+		path         []string
+		line, column int
+		found        bool
+	}{
+		{[]string{"name"}, 1, 8, true},
+		{[]string{"labels", "env"}, 3, 9, true},
+		{[]string{"service", "web"}, 5, 9, true},
+		{[]string{"service", "web", "command"}, 6, 13, true},
+		{[]string{"listener", "1", "port"}, 12, 10, true},
+		{[]string{"missing"}, 0, 0, false},
+	}
+
+	for _, test := range tests {
+		line, column, found := locateHCL(data, test.path)
+		if line != test.line || column != test.column || found != test.found {
+			t.Errorf("%v: got %v:%v %v, want %v:%v %v", test.path, line, column, found, test.line, test.column, test.found)
+		}
+	}
+}
+
+func TestMarshalHCL(t *testing.T) {
+	config := &hclTestConfig{Name: "a \"quoted\"\nname", Port: 80, Timeout: time.Minute, Tags: []string{"x"}}
+	config.Database.User = "u"
+	config.Services = append(config.Services, struct {
+		Name    string `hcl:"name,label"`
+		Command string
+	}{Name: "web", Command: "serve"})
+	config.Backends = map[string]struct{ Address string }{"b": {Address: "1"}}
+
+	data, err := marshalHCL(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decoded := &hclTestConfig{}
+	if err := unmarshalHCL(data, decoded, true); err != nil {
+		t.Fatalf("failed to decode\n%s: %v", data, err)
+	}
+	// empty maps and lists are decoded as such, compare the encoded data
+	if encoded, _ := marshalHCL(decoded); string(encoded) != string(data) {
+		t.Errorf("got\n%s\nwant\n%s", encoded, data)
+	}
+	if decoded.Name != config.Name || decoded.Timeout != config.Timeout || decoded.Services[0] != config.Services[0] {
+		t.Errorf("got %+v, want %+v", decoded, config)
+	}
+}
+
+func TestMarshalHCLNonIdentifierKeys(t *testing.T) {
+	type hclKeysConfig struct {
+		Labels map[string]int
+		Hosts  map[string]struct {
+			Address string
+		} `hcl:"host"`
+		Nested map[string]map[string]int
+		Routes map[string]map[string]struct {
+			Target string
+		} `hcl:"route"`
+		Mixed map[string]interface{}
+	}
+
+	config := &hclKeysConfig{
+		Labels: map[string]int{"a.b": 1, "for": 2, "null": 3, "1st": 4, "ok": 5},
+		Hosts:  map[string]struct{ Address string }{"db.local": {Address: "x"}},
+		Nested: map[string]map[string]int{"team-a": {"x.y": 1}},
+		Routes: map[string]map[string]struct{ Target string }{"GET": {"/": {Target: "index"}}},
+		Mixed:  map[string]interface{}{"a b": map[string]interface{}{"c": "d"}},
+	}
+
+	data, err := marshalHCL(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "labels = {\n  \"1st\" = 4\n  \"a.b\" = 1\n") || !strings.Contains(string(data), "route \"GET\" \"/\" {\n") {
+		t.Errorf("got\n%s", data)
+	}
+
+	decoded := &hclKeysConfig{}
+	if err := unmarshalHCL(data, decoded, true); err != nil {
+		t.Fatalf("failed to decode\n%s: %v", data, err)
+	}
+	if !reflect.DeepEqual(decoded, config) {
+		t.Errorf("got %+v, want %+v from\n%s", decoded, config, data)
+	}
+
+	if _, err := marshalHCL(map[string]int{"a.b": 1}); err == nil {
+		t.Error("expected an error for a body with a non-identifier name")
+	}
+}
+
+func TestLoadHCL(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.hcl":            "name = \"app\"\nport = 80\n\ndatabase {\n  host = \"localhost\"\n  username = \"app\"\n}\n\nbackend \"a\" {\n  address = \"1\"\n}\n",
+		"config.production.hcl": "port = 443\n\ndatabase {\n  host = \"db\"\n}\n\nbackend \"b\" {\n  address = \"2\"\n}\n",
+		"invalid.hcl":           "name = \"app\"\n\ndatabase {\n  host = [1]\n}\n",
+		"syntax.hcl":            "name = \"app\"\nport = \n",
+	})
+
+	var config hclTestConfig
+	if err := New(&Config{Silent: true, Environment: "production"}).Load(&config, filepath.Join(dir, "config.hcl")); err != nil {
+		t.Fatal(err)
+	}
+	// the environment variant is merged into the file, block by block
+	if config.Name != "app" || config.Port != 443 || config.Database.Host != "db" || config.Database.User != "app" {
+		t.Errorf("got %+v, want the environment variant merged", config)
+	}
+	if want := map[string]struct{ Address string }{"a": {"1"}, "b": {"2"}}; !reflect.DeepEqual(config.Backends, want) {
+		t.Errorf("got backends %+v, want %+v", config.Backends, want)
+	}
+
+	// saved files are loaded again
+	file := filepath.Join(t.TempDir(), "saved.hcl")
+	if err := New(nil).Save(&config, file); err != nil {
+		t.Fatal(err)
+	}
+	var saved hclTestConfig
+	if err := New(&Config{Silent: true}).Load(&saved, file); err != nil || !reflect.DeepEqual(saved.Backends, config.Backends) || saved.Database != config.Database {
+		t.Errorf("got %+v and %v after saving", saved, err)
+	}
+
+	// errors carry the source ranges of HCL
+	for name, want := range map[string]string{"invalid.hcl": "invalid.hcl:4:", "syntax.hcl": "syntax.hcl:2:"} {
+		err := New(&Config{Silent: true}).Load(&hclTestConfig{}, filepath.Join(dir, name))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("got %v, want %v", err, want)
+		}
+	}
+}
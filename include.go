@@ -0,0 +1,120 @@
+package configService
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// includeKey is the reserved key configuration files reference other files
+// with. Its value is a path or a list of paths relative to the including
+// file, e.g.
+//
+//	$include: [common.yml, {path: local.yml, optional: true}]
+//
+// Included files are merged in order before the keys of the including file.
+const includeKey = "$include"
+
+type include struct {
+	path     string
+	optional bool
+}
+
+func parseIncludes(value interface{}) ([]include, error) {
+	values, ok := value.([]interface{})
+	if !ok {
+		values = []interface{}{value}
+	}
+
+	var includes []include
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			includes = append(includes, include{path: v})
+		case map[string]interface{}:
+			path, _ := v["path"].(string)
+			optional, _ := v["optional"].(bool)
+			if path == "" {
+				return nil, fmt.Errorf("invalid %v %v, path is missing", includeKey, v)
+			}
+			includes = append(includes, include{path: path, optional: optional})
+		default:
+			return nil, fmt.Errorf("invalid %v %v", includeKey, v)
+		}
+	}
+	return includes, nil
+}
+
+// resolveIncludes merges the files included by the document of file into it.
+// The modification times of included files are added to modTimes, unless it
+// is nil. It returns whether the document contained includes.
+func (configService *ConfigService) resolveIncludes(fsys fileSystem, file string, document interface{}, stack []string, modTimes map[string]time.Time) (interface{}, bool, error) {
+	documentMap, ok := document.(map[string]interface{})
+	if !ok {
+		return document, false, nil
+	}
+
+	value, ok := documentMap[includeKey]
+	if !ok {
+		return document, false, nil
+	}
+	delete(documentMap, includeKey)
+
+	includes, err := parseIncludes(value)
+	if err != nil {
+		return nil, true, fmt.Errorf("%v: %v", file, err)
+	}
+
+	if len(stack) == 0 {
+		stack = []string{fsys.Resolve(file, path.Base(file))}
+	}
+
+	var merged interface{} = map[string]interface{}{}
+	for _, include := range includes {
+		includePath := fsys.Resolve(file, include.path)
+		for i, stackFile := range stack {
+			if stackFile == includePath {
+				return nil, true, fmt.Errorf("include cycle: %v -> %v", strings.Join(stack[i:], " -> "), includePath)
+			}
+		}
+
+		fileInfo, err := fsys.Stat(includePath)
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			if include.optional {
+				// watch the include, so it is loaded once it is created
+				if modTimes != nil && fsys.Watched() {
+					modTimes[includePath] = time.Time{}
+				}
+				if configService.Config.Debug || configService.Config.Verbose {
+					configService.logf("Skipping optional include %v of %v\n", includePath, file)
+				}
+				continue
+			}
+			return nil, true, fmt.Errorf("failed to include %v from %v", includePath, file)
+		}
+		if modTimes != nil && fsys.Watched() {
+			modTimes[includePath] = fileInfo.ModTime()
+		}
+
+		data, err := fsys.ReadFile(includePath)
+		if err != nil {
+			return nil, true, err
+		}
+
+		included, _, err := decodeDocument(configService.dataFormat(fileFormat(includePath), data), data)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to include %v from %v: %v", includePath, file, err)
+		}
+		if _, err := configService.migrateDocument(includePath, included); err != nil {
+			return nil, true, err
+		}
+
+		if included, _, err = configService.resolveIncludes(fsys, includePath, included, append(stack, includePath), modTimes); err != nil {
+			return nil, true, err
+		}
+		merged = mergeDocuments(merged, included)
+	}
+
+	return mergeDocuments(merged, documentMap), true, nil
+}
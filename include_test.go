@@ -0,0 +1,152 @@
+package configService
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type includeConfig struct {
+	Name  string
+	Port  int
+	Hosts []string
+	DB    struct {
+		Host string
+		User string
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  includeConfig
+		err   string
+	}{
+		{"single path", map[string]string{
+			"config.yml": "$include: common.yml\nport: 2\n",
+			"common.yml": "name: common\nport: 1\n",
+		}, includeConfig{Name: "common", Port: 2}, ""},
+		{"merged in order", map[string]string{
+			"config.yml": "$include: [a.yml, b.json]\ndb:\n  user: root\n",
+			"a.yml":      "db:\n  host: a\n  user: a\nhosts: [a]\n",
+			"b.json":     "{\"db\": {\"host\": \"b\"}}",
+		}, includeConfig{Hosts: []string{"a"}, DB: struct{ Host, User string }{"b", "root"}}, ""},
+		{"relative to the including file", map[string]string{
+			"config.yml":            "$include: conf/db.yml\n",
+			"conf/db.yml":           "$include: ../shared/name.toml\ndb:\n  host: h\n",
+			"shared/name.toml":      "name = \"shared\"\n",
+			"conf/shared/name.toml": "name = \"wrong\"\n",
+		}, includeConfig{Name: "shared", DB: struct{ Host, User string }{Host: "h"}}, ""},
+		{"optional", map[string]string{
+			"config.yml": "$include: [{path: missing.yml, optional: true}, common.yml]\n",
+			"common.yml": "port: 1\n",
+		}, includeConfig{Port: 1}, ""},
+		{"missing", map[string]string{
+			"config.yml": "$include: missing.yml\n",
+		}, includeConfig{}, "failed to include"},
+		{"invalid", map[string]string{
+			"config.yml": "$include: [{optional: true}]\n",
+		}, includeConfig{}, "path is missing"},
+		{"cycle", map[string]string{
+			"config.yml": "$include: a.yml\n",
+			"a.yml":      "$include: b.yml\n",
+			"b.yml":      "$include: a.yml\n",
+		}, includeConfig{}, "include cycle: "},
+		{"self", map[string]string{
+			"config.yml": "$include: config.yml\n",
+		}, includeConfig{}, "include cycle: "},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTestFiles(t, test.files)
+			var config includeConfig
+			err := New(&Config{Silent: true}).Load(&config, filepath.Join(dir, "config.yml"))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.want) {
+				t.Errorf("got %+v, want %+v", config, test.want)
+			}
+		})
+	}
+}
+
+func TestIncludeCycleNamesFiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml": "$include: a.yml\n",
+		"a.yml":      "$include: config.yml\n",
+	})
+	err := New(&Config{Silent: true}).Load(&includeConfig{}, filepath.Join(dir, "config.yml"))
+	want := "include cycle: " + filepath.Join(dir, "config.yml") + " -> " + filepath.Join(dir, "a.yml") + " -> " + filepath.Join(dir, "config.yml")
+	if err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestReloadIncludedFiles(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml": "$include: [common.yml, {path: local.yml, optional: true}]\n",
+		"common.yml": "port: 1\n",
+	})
+
+	reloaded := make(chan includeConfig, 1)
+	service := New(&Config{
+		Silent:             true,
+		AutoReload:         true,
+		AutoReloadInterval: 10 * time.Millisecond,
+		AutoReloadCallback: func(config interface{}) {
+			reloaded <- *config.(*includeConfig)
+		},
+	})
+	config := &includeConfig{}
+	if err := service.Load(config, filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 1 {
+		t.Fatalf("got %+v", config)
+	}
+
+	wait := func() includeConfig {
+		t.Helper()
+		select {
+		case config := <-reloaded:
+			return config
+		case <-time.After(5 * time.Second):
+			t.Fatal("config wasn't reloaded")
+		}
+		return includeConfig{}
+	}
+
+	// a changed include
+	file := filepath.Join(dir, "common.yml")
+	if err := ioutil.WriteFile(file, []byte("port: 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if got := wait(); got.Port != 2 {
+		t.Errorf("got %+v after changing common.yml", got)
+	}
+
+	// an optional include which is created
+	if err := ioutil.WriteFile(filepath.Join(dir, "local.yml"), []byte("name: local\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := wait(); got.Name != "local" || got.Port != 2 {
+		t.Errorf("got %+v after creating local.yml", got)
+	}
+}
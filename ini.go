@@ -0,0 +1,156 @@
+package configService
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FormatINI is the format of INI files. Sections map to nested structs and
+// maps, e.g. [database.replica], and items of lists are addressed by their
+// index, e.g. hosts.0 = a.
+const FormatINI Format = "ini"
+
+func init() {
+	registerFormat(&registeredFormat{
+		name:           FormatINI,
+		extensions:     []string{".ini"},
+		decoder:        unmarshalINI,
+		encoder:        marshalINI,
+		decodeDocument: decodeStringTreeDocument(parseINI),
+		locate:         locateStringTree(parseINI),
+		stringTree:     true,
+	})
+}
+
+func unmarshalINI(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+	tree, err := parseINI(data, nil)
+	if err != nil {
+		return err
+	}
+	return decodeStringTree(tree, config, errorOnUnmatchedKeys)
+}
+
+// parseINI parses an INI file into a string tree. Keys and section names are
+// split at dots. The line of each key is added to positions unless it is nil.
+func parseINI(data []byte, positions map[string]int) (map[string]interface{}, error) {
+	tree := map[string]interface{}{}
+	var section []string
+
+	for number, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, &syntaxError{line: number + 1, message: "unterminated section"}
+			}
+			name := strings.TrimSpace(line[1:end])
+			if name == "" {
+				return nil, &syntaxError{line: number + 1, message: "empty section name"}
+			}
+			section = splitINIKey(name)
+			continue
+		}
+
+		separator := strings.IndexAny(line, "=:")
+		if separator <= 0 {
+			return nil, &syntaxError{line: number + 1, message: "expected key = value"}
+		}
+
+		keys := append(append([]string{}, section...), splitINIKey(line[:separator])...)
+		if positions != nil {
+			positions[strings.Join(keys, ".")] = number + 1
+		}
+		if err := setTreeValue(tree, keys, parseINIValue(strings.TrimSpace(line[separator+1:]))); err != nil {
+			return nil, &syntaxError{line: number + 1, message: err.Error()}
+		}
+	}
+	return tree, nil
+}
+
+func splitINIKey(key string) []string {
+	keys := strings.Split(key, ".")
+	for i := range keys {
+		keys[i] = strings.TrimSpace(keys[i])
+	}
+	return keys
+}
+
+// parseINIValue removes quotes and inline comments from a value
+func parseINIValue(value string) string {
+	if len(value) >= 2 && value[0] == '"' {
+		if end := strings.LastIndexByte(value, '"'); end > 0 {
+			if unquoted, err := strconv.Unquote(value[:end+1]); err == nil {
+				return unquoted
+			}
+		}
+	}
+	if len(value) >= 2 && value[0] == '\'' {
+		if end := strings.IndexByte(value[1:], '\''); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+
+	for _, comment := range []string{" ;", " #", "\t;", "\t#"} {
+		if index := strings.Index(value, comment); index >= 0 {
+			value = value[:index]
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+// marshalINI encodes config as INI file. Values inside nested structs and
+// maps are written to sections, those inside lists use indexed keys.
+func marshalINI(config interface{}) ([]byte, error) {
+	var sections []string
+	sectionEntries := map[string][]string{}
+
+	for _, entry := range flattenValue(reflect.ValueOf(config), nil) {
+		// the section ends before the key of the value or the first list
+		split := len(entry.keys) - 1
+		for i, key := range entry.keys {
+			if i > 0 && isIndexKey(key) {
+				split = i - 1
+				break
+			}
+		}
+
+		section := strings.Join(entry.keys[:split], ".")
+		if _, ok := sectionEntries[section]; !ok {
+			sections = append(sections, section)
+		}
+		sectionEntries[section] = append(sectionEntries[section], fmt.Sprintf("%v = %v", strings.Join(entry.keys[split:], "."), formatINIValue(entry.value)))
+	}
+
+	var buffer bytes.Buffer
+	for _, line := range sectionEntries[""] {
+		buffer.WriteString(line + "\n")
+	}
+	for _, section := range sections {
+		if section == "" {
+			continue
+		}
+		if buffer.Len() > 0 {
+			buffer.WriteString("\n")
+		}
+		buffer.WriteString("[" + section + "]\n")
+		for _, line := range sectionEntries[section] {
+			buffer.WriteString(line + "\n")
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// formatINIValue quotes values which would be changed by parseINIValue
+func formatINIValue(value string) string {
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "\"';#\n\r") {
+		return strconv.Quote(value)
+	}
+	return value
+}
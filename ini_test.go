@@ -0,0 +1,133 @@
+package configService
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseINI(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]interface{}
+	}{
+		{"top level", "a = 1\nb: 2\n", map[string]interface{}{"a": "1", "b": "2"}},
+		{"comments", "; comment\n# comment\na = 1 ; inline\nb = 2 # inline\nc = 3#4\n", map[string]interface{}{"a": "1", "b": "2", "c": "3#4"}},
+		{"sections", "a = 1\n[db]\nhost = h\n[db.replica]\nhost = r\n", map[string]interface{}{"a": "1", "db": map[string]interface{}{"host": "h", "replica": map[string]interface{}{"host": "r"}}}},
+		{"dotted keys", "[db]\nreplica . host = r\n", map[string]interface{}{"db": map[string]interface{}{"replica": map[string]interface{}{"host": "r"}}}},
+		{"double quoted", `a = "1 ; 2\t\"3\"" ; comment`, map[string]interface{}{"a": "1 ; 2\t\"3\""}},
+		{"single quoted", `a = '1 # 2\t' # comment`, map[string]interface{}{"a": `1 # 2\t`}},
+		{"empty value", "a =\n", map[string]interface{}{"a": ""}},
+		{"value with separators", "a = b=c:d\n", map[string]interface{}{"a": "b=c:d"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseINI([]byte(test.data), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseINIErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"unterminated section", "a = 1\n[db\n", "line 2: unterminated section"},
+		{"empty section", "[ ]\n", "line 1: empty section name"},
+		{"missing separator", "[db]\nhost\n", "line 2: expected key = value"},
+		{"missing key", "= 1\n", "line 1: expected key = value"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseINI([]byte(test.data), nil)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+type stringTreeConfig struct {
+	Name    string
+	Debug   bool
+	Timeout time.Duration
+	Hosts   []string
+	Labels  map[string]int
+	DB      struct {
+		Host string
+		Port int
+	}
+}
+
+func TestLoadStringTreeFormats(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.ini":        "name = 007\ndebug = true\ntimeout = 1s\nhosts.0 = a\nhosts.1 = b\n[labels]\nx = 1\n[db]\nhost = h\nport = 5432\n",
+		"config.properties": "name=007\ndebug=true\ntimeout=1s\nhosts.0=a\nhosts.1=b\nlabels.x=1\ndb.host=h\ndb.port=5432\n",
+	})
+	want := stringTreeConfig{Name: "007", Debug: true, Timeout: time.Second, Hosts: []string{"a", "b"}, Labels: map[string]int{"x": 1}}
+	want.DB.Host, want.DB.Port = "h", 5432
+
+	for _, file := range []string{"config.ini", "config.properties"} {
+		t.Run(file, func(t *testing.T) {
+			var config stringTreeConfig
+			if err := New(&Config{Silent: true}).Load(&config, filepath.Join(dir, file)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, want) {
+				t.Errorf("got %+v, want %+v", config, want)
+			}
+
+			// saved files are loaded again
+			saved := filepath.Join(t.TempDir(), file)
+			if err := New(nil).Save(&config, saved); err != nil {
+				t.Fatal(err)
+			}
+			var reloaded stringTreeConfig
+			if err := New(&Config{Silent: true}).Load(&reloaded, saved); err != nil || !reflect.DeepEqual(reloaded, want) {
+				t.Errorf("got %+v and %v after saving", reloaded, err)
+			}
+		})
+	}
+}
+
+func TestLoadStringTreeFormatErrors(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"unknown.ini":        "name = a\n[db]\nhots = h\n",
+		"unknown.properties": "name=a\ndb.hots=h\n",
+		"invalid.ini":        "[db]\nport = many\n",
+		"invalid.properties": "db.port=many\n",
+	})
+
+	tests := []struct {
+		file string
+		err  string
+	}{
+		{"unknown.ini", "unknown.ini:3:1: db.hots"},
+		{"unknown.properties", "unknown.properties:2:1: db.hots"},
+		{"invalid.ini", `invalid.ini:2:1: db.port: can't use "many" as int`},
+		{"invalid.properties", `invalid.properties:1:1: db.port: can't use "many" as int`},
+	}
+	for _, test := range tests {
+		err := New(&Config{Silent: true, ErrorOnUnmatchedKeys: true}).Load(&stringTreeConfig{}, filepath.Join(dir, test.file))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v: got %v, want %v", test.file, err, test.err)
+		}
+	}
+
+	// unknown keys are ignored unless ErrorOnUnmatchedKeys is set
+	if err := New(&Config{Silent: true}).Load(&stringTreeConfig{}, filepath.Join(dir, "unknown.ini")); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,204 @@
+package configService
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// FormatJSONC is the format of JSON files with comments, trailing commas,
+// unquoted keys and single quoted strings, like .jsonc and .json5 files.
+// They are converted to standard JSON before they are decoded, keeping the
+// line numbers of the original data.
+const FormatJSONC Format = "jsonc"
+
+func init() {
+	registerFormat(&registeredFormat{
+		name:       FormatJSONC,
+		extensions: []string{".jsonc", ".json5"},
+		decoder: func(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+			return unmarshalJSON(standardizeJSON(data), config, errorOnUnmatchedKeys)
+		},
+		encoder: json.Marshal,
+		decodeDocument: func(data []byte) (interface{}, error) {
+			return decodeJSONDocument(standardizeJSON(data))
+		},
+		locate: func(data []byte, path []string) (int, int, bool) {
+			return locateJSON(standardizeJSON(data), path)
+		},
+	})
+	RegisterSniffer(string(FormatJSONC), sniffJSONC)
+}
+
+// sniffJSONC detects JSON data starting with a comment
+func sniffJSONC(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("//")) && !bytes.HasPrefix(data, []byte("/*")) {
+		return false
+	}
+	return sniffJSON(standardizeJSON(data))
+}
+
+// dataFormat returns the format data is decoded with. It is detected if
+// format is empty, and JSON is decoded leniently if LenientJSON is set.
+func (configService *ConfigService) dataFormat(format Format, data []byte) Format {
+	if format == "" {
+		format = detectFormat(data)
+	}
+	if format == FormatJSON && configService.Config.LenientJSON {
+		return FormatJSONC
+	}
+	return format
+}
+
+// standardizeJSON converts lenient JSON into standard JSON. Comments and
+// trailing commas are replaced by spaces, unquoted keys are quoted and single
+// quoted strings double quoted. Invalid data is left for the JSON decoder to
+// report.
+func standardizeJSON(data []byte) []byte {
+	converter := &jsonConverter{data: data}
+	converter.convert()
+	return converter.output.Bytes()
+}
+
+type jsonConverter struct {
+	data   []byte
+	pos    int
+	output bytes.Buffer
+}
+
+func (converter *jsonConverter) convert() {
+	for converter.pos < len(converter.data) {
+		c := converter.data[converter.pos]
+		switch {
+		case c == '"':
+			converter.copyString()
+		case c == '\'':
+			converter.convertSingleQuoted()
+		case c == '/' && converter.commentLength(converter.pos) > 0:
+			end := converter.pos + converter.commentLength(converter.pos)
+			converter.blank(converter.data[converter.pos:end])
+			converter.pos = end
+		case c == ',':
+			if next := converter.nextSignificant(converter.pos + 1); next < len(converter.data) && (converter.data[next] == '}' || converter.data[next] == ']') {
+				converter.output.WriteByte(' ')
+			} else {
+				converter.output.WriteByte(c)
+			}
+			converter.pos++
+		case isJSONIdentifierStart(c):
+			start := converter.pos
+			for converter.pos < len(converter.data) && isJSONIdentifierPart(converter.data[converter.pos]) {
+				converter.pos++
+			}
+			identifier := converter.data[start:converter.pos]
+			if next := converter.nextSignificant(converter.pos); next < len(converter.data) && converter.data[next] == ':' {
+				converter.output.WriteByte('"')
+				converter.output.Write(identifier)
+				converter.output.WriteByte('"')
+			} else {
+				converter.output.Write(identifier)
+			}
+		default:
+			converter.output.WriteByte(c)
+			converter.pos++
+		}
+	}
+}
+
+// copyString copies a double quoted string
+func (converter *jsonConverter) copyString() {
+	converter.output.WriteByte('"')
+	converter.pos++
+	for converter.pos < len(converter.data) {
+		c := converter.data[converter.pos]
+		converter.output.WriteByte(c)
+		converter.pos++
+		if c == '\\' && converter.pos < len(converter.data) {
+			converter.output.WriteByte(converter.data[converter.pos])
+			converter.pos++
+		} else if c == '"' {
+			return
+		}
+	}
+}
+
+// convertSingleQuoted converts a single quoted string into a double quoted
+// one
+func (converter *jsonConverter) convertSingleQuoted() {
+	converter.output.WriteByte('"')
+	converter.pos++
+	for converter.pos < len(converter.data) {
+		c := converter.data[converter.pos]
+		converter.pos++
+		switch {
+		case c == '\\' && converter.pos < len(converter.data):
+			escaped := converter.data[converter.pos]
+			converter.pos++
+			if escaped != '\'' {
+				converter.output.WriteByte('\\')
+			}
+			converter.output.WriteByte(escaped)
+		case c == '"':
+			converter.output.WriteString(`\"`)
+		case c == '\'':
+			converter.output.WriteByte('"')
+			return
+		default:
+			converter.output.WriteByte(c)
+		}
+	}
+}
+
+// commentLength returns the length of the comment starting at pos, or 0 if
+// there is none
+func (converter *jsonConverter) commentLength(pos int) int {
+	rest := converter.data[pos:]
+	switch {
+	case bytes.HasPrefix(rest, []byte("//")):
+		if end := bytes.IndexByte(rest, '\n'); end >= 0 {
+			return end
+		}
+		return len(rest)
+	case bytes.HasPrefix(rest, []byte("/*")):
+		if end := bytes.Index(rest[2:], []byte("*/")); end >= 0 {
+			return end + 4
+		}
+		return len(rest)
+	}
+	return 0
+}
+
+// nextSignificant returns the position of the next character after pos,
+// which is neither whitespace nor part of a comment
+func (converter *jsonConverter) nextSignificant(pos int) int {
+	for pos < len(converter.data) {
+		switch c := converter.data[pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+		case c == '/' && converter.commentLength(pos) > 0:
+			pos += converter.commentLength(pos)
+		default:
+			return pos
+		}
+	}
+	return pos
+}
+
+// blank writes spaces for the characters of data, keeping line breaks
+func (converter *jsonConverter) blank(data []byte) {
+	for _, c := range data {
+		if c == '\n' || c == '\r' {
+			converter.output.WriteByte(c)
+		} else {
+			converter.output.WriteByte(' ')
+		}
+	}
+}
+
+func isJSONIdentifierStart(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isJSONIdentifierPart(c byte) bool {
+	return isJSONIdentifierStart(c) || ('0' <= c && c <= '9') || c == '-'
+}
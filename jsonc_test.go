@@ -0,0 +1,112 @@
+package configService
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStandardizeJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want interface{}
+	}{
+		{"standard", `{"a": [1, 2], "b": null}`, map[string]interface{}{"a": []interface{}{1.0, 2.0}, "b": nil}},
+		{"line comment", "{\n  // comment\n  \"a\": 1 // trailing\n}", map[string]interface{}{"a": 1.0}},
+		{"block comment", "{/* a: 2, */ \"a\": /* inline */ 1}", map[string]interface{}{"a": 1.0}},
+		{"trailing commas", `{"a": [1, 2,], "b": {"c": true,},}`, map[string]interface{}{"a": []interface{}{1.0, 2.0}, "b": map[string]interface{}{"c": true}}},
+		{"trailing comma before comment", "{\"a\": 1, // comment\n}", map[string]interface{}{"a": 1.0}},
+		{"comma inside string", `{"a": "1,}", "b": "2,]"}`, map[string]interface{}{"a": "1,}", "b": "2,]"}},
+		{"comment inside string", `{"a": "http://host/*path*/"}`, map[string]interface{}{"a": "http://host/*path*/"}},
+		{"escaped quote", `{"a": "\"b\", // c"}`, map[string]interface{}{"a": `"b", // c`}},
+		{"unquoted keys", `{a: 1, $b_c-d: true, e1: null}`, map[string]interface{}{"a": 1.0, "$b_c-d": true, "e1": nil}},
+		{"single quoted", `{'a': 'it\'s "quoted"', b: '\n'}`, map[string]interface{}{"a": `it's "quoted"`, "b": "\n"}},
+		{"literals", `[true, false, null]`, []interface{}{true, false, nil}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			standardized := standardizeJSON([]byte(test.data))
+			var got interface{}
+			if err := json.Unmarshal(standardized, &got); err != nil {
+				t.Fatalf("invalid JSON %s: %v", standardized, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestStandardizeJSONKeepsLines(t *testing.T) {
+	data := "{\n  /* multi\n     line */\n  a: 1, // one\n  'b': 2,\n}\n"
+	standardized := standardizeJSON([]byte(data))
+	if got, want := bytes.Count(standardized, []byte("\n")), bytes.Count([]byte(data), []byte("\n")); got != want {
+		t.Errorf("got %v lines, want %v", got, want)
+	}
+
+	line, _, ok := locateJSON(standardized, []string{"b"})
+	if !ok || line != 5 {
+		t.Errorf("got b at line %v, want 5", line)
+	}
+}
+
+type jsoncConfig struct {
+	Name  string
+	Port  int
+	Hosts []string
+}
+
+func TestLoadJSONC(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.jsonc":      "{\n  // the name\n  \"name\": \"jsonc\",\n  /* the port */ \"port\": 1,\n}\n",
+		"config.json5":      "{name: 'json5', hosts: ['a', 'b',],}\n",
+		"config.json":       "{\"port\": 2, // comment\n}\n",
+		"config.production.jsonc": "{port: 3}\n",
+		"unknown.jsonc":     "{\n  // comment\n  name: 'a',\n  hots: [],\n}\n",
+		"invalid.jsonc":     "{\n  // comment\n  port: 'one',\n}\n",
+	})
+	load := func(config *Config, file string) (jsoncConfig, error) {
+		var result jsoncConfig
+		err := New(config).Load(&result, filepath.Join(dir, file))
+		return result, err
+	}
+
+	if got, err := load(&Config{Silent: true}, "config.jsonc"); err != nil || !reflect.DeepEqual(got, jsoncConfig{Name: "jsonc", Port: 1}) {
+		t.Errorf("got %+v and %v for config.jsonc", got, err)
+	}
+	if got, err := load(&Config{Silent: true}, "config.json5"); err != nil || !reflect.DeepEqual(got, jsoncConfig{Name: "json5", Hosts: []string{"a", "b"}}) {
+		t.Errorf("got %+v and %v for config.json5", got, err)
+	}
+	if got, err := load(&Config{Silent: true, Environment: "production"}, "config.jsonc"); err != nil || got.Port != 3 {
+		t.Errorf("got %+v and %v, want the environment variant", got, err)
+	}
+
+	// .json files are strict unless LenientJSON is set
+	if _, err := load(&Config{Silent: true}, "config.json"); err == nil {
+		t.Error("expected an error for a comment in config.json")
+	}
+	if got, err := load(&Config{Silent: true, LenientJSON: true}, "config.json"); err != nil || got.Port != 2 {
+		t.Errorf("got %+v and %v for config.json with LenientJSON", got, err)
+	}
+
+	// errors point at the lines of the original file
+	_, err := load(&Config{Silent: true, ErrorOnUnmatchedKeys: true}, "unknown.jsonc")
+	if err == nil || !strings.Contains(err.Error(), "unknown.jsonc:4:3: hots") {
+		t.Errorf("got %v, want the unknown key located", err)
+	}
+	_, err = load(&Config{Silent: true}, "invalid.jsonc")
+	if err == nil || !strings.Contains(err.Error(), "invalid.jsonc:3:") {
+		t.Errorf("got %v, want the invalid value located", err)
+	}
+
+	// data starting with a comment is detected as JSONC
+	var config jsoncConfig
+	if err := New(&Config{Silent: true}).LoadBytes(&config, []byte("// comment\n{port: 4,}"), ""); err != nil || config.Port != 4 {
+		t.Errorf("got %+v and %v for detected data", config, err)
+	}
+}
@@ -0,0 +1,478 @@
+package configService
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaError is returned by Load if a configuration file doesn't match the
+// JSON Schema it is validated against
+type SchemaError struct {
+	File       string
+	Violations []SchemaViolation
+}
+
+// SchemaViolation describes a value of a configuration file which doesn't
+// match the schema
+type SchemaViolation struct {
+	// Pointer is the JSON pointer of the value inside the file
+	Pointer string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		pointer := violation.Pointer
+		if pointer == "" {
+			pointer = "/"
+		}
+		messages[i] = fmt.Sprintf("%v:%v:%v: %v: %v", e.File, violation.Line, violation.Column, pointer, violation.Message)
+	}
+	return "Config file doesn't match the schema: " + strings.Join(messages, "; ")
+}
+
+// getSchemaValidator returns the validator configuration files are checked
+// with, or nil if they shouldn't be validated
+func (configService *ConfigService) getSchemaValidator(config interface{}) (*schemaValidator, error) {
+	schema := configService.Config.Schema
+	if len(schema) == 0 {
+		if !configService.Config.ValidateSchema {
+			return nil, nil
+		}
+
+		// files are merged, so a single file doesn't need all required keys
+		generated, err := generateSchema(config, false)
+		if err != nil {
+			return nil, err
+		}
+		if schema, err = json.Marshal(generated); err != nil {
+			return nil, err
+		}
+	}
+	return newSchemaValidator(schema)
+}
+
+// schemaValidator validates documents against a JSON Schema. It implements
+// the validation keywords of draft 2020-12 and local references, but neither
+// remote references nor annotations like format.
+type schemaValidator struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+func newSchemaValidator(schema []byte) (*schemaValidator, error) {
+	var root interface{}
+	decoder := json.NewDecoder(bytes.NewReader(schema))
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+	return &schemaValidator{root: root, patterns: map[string]*regexp.Regexp{}}, nil
+}
+
+// validateDocument validates the decoded configuration file, using locate to
+// find the positions of invalid values
+func (validator *schemaValidator) validateDocument(file string, document interface{}, locate func([]string) (int, int, bool)) error {
+	violations := validator.validate(validator.root, document, "")
+	if len(violations) == 0 {
+		return nil
+	}
+
+	for i := range violations {
+		violations[i].Line, violations[i].Column, _ = locate(splitPointer(violations[i].Pointer))
+	}
+	return &SchemaError{File: file, Violations: violations}
+}
+
+func (validator *schemaValidator) isValid(schema, instance interface{}) bool {
+	return len(validator.validate(schema, instance, "")) == 0
+}
+
+func (validator *schemaValidator) validate(schema, instance interface{}, pointer string) []SchemaViolation {
+	if allowed, ok := schema.(bool); ok {
+		if !allowed {
+			return []SchemaViolation{{Pointer: pointer, Message: "no value allowed"}}
+		}
+		return nil
+	}
+
+	keywords, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var violations []SchemaViolation
+	add := func(format string, args ...interface{}) {
+		violations = append(violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := keywords["$ref"].(string); ok {
+		if target, err := validator.resolve(ref); err != nil {
+			add("%v", err)
+		} else {
+			violations = append(violations, validator.validate(target, instance, pointer)...)
+		}
+	}
+
+	if types, ok := keywords["type"]; ok && !matchesType(types, instance) {
+		add("expected %v, got %v", types, jsonType(instance))
+		return violations
+	}
+
+	if enum, ok := keywords["enum"].([]interface{}); ok {
+		found := false
+		for _, value := range enum {
+			if equalValues(value, instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			add("must be one of %v", enum)
+		}
+	}
+
+	if value, ok := keywords["const"]; ok && !equalValues(value, instance) {
+		add("must be %v", value)
+	}
+
+	switch value := instance.(type) {
+	case map[string]interface{}:
+		violations = append(violations, validator.validateObject(keywords, value, pointer)...)
+	case []interface{}:
+		violations = append(violations, validator.validateArray(keywords, value, pointer)...)
+	case string:
+		length := utf8.RuneCountInString(value)
+		if limit, ok := toNumber(keywords["minLength"]); ok && float64(length) < limit {
+			add("must be at least %v characters long", limit)
+		}
+		if limit, ok := toNumber(keywords["maxLength"]); ok && float64(length) > limit {
+			add("must be at most %v characters long", limit)
+		}
+		if pattern, ok := keywords["pattern"].(string); ok {
+			if expression, err := validator.compile(pattern); err != nil {
+				add("invalid pattern %v: %v", pattern, err)
+			} else if !expression.MatchString(value) {
+				add("must match %v", pattern)
+			}
+		}
+	default:
+		if number, ok := toNumber(instance); ok {
+			if limit, ok := toNumber(keywords["minimum"]); ok && number < limit {
+				add("must be >= %v", limit)
+			}
+			if limit, ok := toNumber(keywords["maximum"]); ok && number > limit {
+				add("must be <= %v", limit)
+			}
+			if limit, ok := toNumber(keywords["exclusiveMinimum"]); ok && number <= limit {
+				add("must be > %v", limit)
+			}
+			if limit, ok := toNumber(keywords["exclusiveMaximum"]); ok && number >= limit {
+				add("must be < %v", limit)
+			}
+			if divisor, ok := toNumber(keywords["multipleOf"]); ok && divisor != 0 {
+				if quotient := number / divisor; quotient != math.Trunc(quotient) {
+					add("must be a multiple of %v", divisor)
+				}
+			}
+		}
+	}
+
+	if schemas, ok := keywords["allOf"].([]interface{}); ok {
+		for _, subSchema := range schemas {
+			violations = append(violations, validator.validate(subSchema, instance, pointer)...)
+		}
+	}
+
+	if schemas, ok := keywords["anyOf"].([]interface{}); ok {
+		valid := false
+		for _, subSchema := range schemas {
+			if validator.isValid(subSchema, instance) {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			add("must match at least one schema of anyOf")
+		}
+	}
+
+	if schemas, ok := keywords["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, subSchema := range schemas {
+			if validator.isValid(subSchema, instance) {
+				matches++
+			}
+		}
+		if matches != 1 {
+			add("must match exactly one schema of oneOf, matches %v", matches)
+		}
+	}
+
+	if subSchema, ok := keywords["not"]; ok && validator.isValid(subSchema, instance) {
+		add("must not match the schema of not")
+	}
+
+	if condition, ok := keywords["if"]; ok {
+		if validator.isValid(condition, instance) {
+			if then, ok := keywords["then"]; ok {
+				violations = append(violations, validator.validate(then, instance, pointer)...)
+			}
+		} else if otherwise, ok := keywords["else"]; ok {
+			violations = append(violations, validator.validate(otherwise, instance, pointer)...)
+		}
+	}
+
+	return violations
+}
+
+func (validator *schemaValidator) validateObject(keywords map[string]interface{}, object map[string]interface{}, pointer string) []SchemaViolation {
+	var violations []SchemaViolation
+	properties, _ := keywords["properties"].(map[string]interface{})
+	patternProperties, _ := keywords["patternProperties"].(map[string]interface{})
+
+	if required, ok := keywords["required"].([]interface{}); ok {
+		for _, key := range required {
+			if name, ok := key.(string); ok {
+				if _, exists := object[name]; !exists {
+					violations = append(violations, SchemaViolation{Pointer: pointer, Message: "missing required key " + name})
+				}
+			}
+		}
+	}
+
+	if limit, ok := toNumber(keywords["minProperties"]); ok && float64(len(object)) < limit {
+		violations = append(violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf("must have at least %v keys", limit)})
+	}
+	if limit, ok := toNumber(keywords["maxProperties"]); ok && float64(len(object)) > limit {
+		violations = append(violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf("must have at most %v keys", limit)})
+	}
+
+	// iterate sorted keys, so violations are reported in a stable order
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := object[key]
+		keyPointer := pointer + "/" + escapePointer(key)
+		matched := false
+
+		if subSchema, ok := properties[key]; ok {
+			matched = true
+			violations = append(violations, validator.validate(subSchema, value, keyPointer)...)
+		}
+
+		for pattern, subSchema := range patternProperties {
+			if expression, err := validator.compile(pattern); err == nil && expression.MatchString(key) {
+				matched = true
+				violations = append(violations, validator.validate(subSchema, value, keyPointer)...)
+			}
+		}
+
+		if additional, ok := keywords["additionalProperties"]; ok && !matched {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				violations = append(violations, SchemaViolation{Pointer: keyPointer, Message: "unknown key " + key})
+			} else {
+				violations = append(violations, validator.validate(additional, value, keyPointer)...)
+			}
+		}
+	}
+	return violations
+}
+
+func (validator *schemaValidator) validateArray(keywords map[string]interface{}, array []interface{}, pointer string) []SchemaViolation {
+	var violations []SchemaViolation
+
+	prefixItems, _ := keywords["prefixItems"].([]interface{})
+	for i, value := range array {
+		itemPointer := fmt.Sprintf("%v/%v", pointer, i)
+		if i < len(prefixItems) {
+			violations = append(violations, validator.validate(prefixItems[i], value, itemPointer)...)
+		} else if items, ok := keywords["items"]; ok {
+			violations = append(violations, validator.validate(items, value, itemPointer)...)
+		}
+	}
+
+	if limit, ok := toNumber(keywords["minItems"]); ok && float64(len(array)) < limit {
+		violations = append(violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf("must have at least %v items", limit)})
+	}
+	if limit, ok := toNumber(keywords["maxItems"]); ok && float64(len(array)) > limit {
+		violations = append(violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf("must have at most %v items", limit)})
+	}
+
+	if unique, _ := keywords["uniqueItems"].(bool); unique {
+		for i := range array {
+			for j := i + 1; j < len(array); j++ {
+				if equalValues(array[i], array[j]) {
+					violations = append(violations, SchemaViolation{Pointer: pointer, Message: fmt.Sprintf("items %v and %v are equal", i, j)})
+				}
+			}
+		}
+	}
+
+	if contains, ok := keywords["contains"]; ok {
+		found := false
+		for _, value := range array {
+			if validator.isValid(contains, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			violations = append(violations, SchemaViolation{Pointer: pointer, Message: "no item matches the schema of contains"})
+		}
+	}
+	return violations
+}
+
+// resolve resolves a reference to a location inside the schema
+func (validator *schemaValidator) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("unsupported reference %v", ref)
+	}
+
+	target := validator.root
+	for _, token := range splitPointer(strings.TrimPrefix(ref, "#")) {
+		object, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("can't resolve reference %v", ref)
+		}
+		if target, ok = object[token]; !ok {
+			return nil, fmt.Errorf("can't resolve reference %v", ref)
+		}
+	}
+	return target, nil
+}
+
+func (validator *schemaValidator) compile(pattern string) (*regexp.Regexp, error) {
+	if expression, ok := validator.patterns[pattern]; ok {
+		return expression, nil
+	}
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	validator.patterns[pattern] = expression
+	return expression, nil
+}
+
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+	if number, ok := toNumber(value); ok {
+		if number == math.Trunc(number) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func matchesType(types, value interface{}) bool {
+	actual := jsonType(value)
+	matches := func(expected interface{}) bool {
+		return expected == actual || (expected == "number" && actual == "integer")
+	}
+
+	if list, ok := types.([]interface{}); ok {
+		for _, expected := range list {
+			if matches(expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return matches(types)
+}
+
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func equalValues(a, b interface{}) bool {
+	if numberA, ok := toNumber(a); ok {
+		numberB, ok := toNumber(b)
+		return ok && numberA == numberB
+	}
+
+	switch valueA := a.(type) {
+	case map[string]interface{}:
+		valueB, ok := b.(map[string]interface{})
+		if !ok || len(valueA) != len(valueB) {
+			return false
+		}
+		for key, elem := range valueA {
+			if other, ok := valueB[key]; !ok || !equalValues(elem, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		valueB, ok := b.([]interface{})
+		if !ok || len(valueA) != len(valueB) {
+			return false
+		}
+		for i := range valueA {
+			if !equalValues(valueA[i], valueB[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
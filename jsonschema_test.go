@@ -0,0 +1,166 @@
+package configService
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		want     []string
+	}{
+		{"valid", `{"type": "object", "properties": {"port": {"type": "integer"}}}`, `{"port": 80}`, nil},
+		{"type", `{"properties": {"port": {"type": "integer"}}}`, `{"port": "80"}`, []string{"/port: expected integer, got string"}},
+		{"type list", `{"type": ["string", "null"]}`, `null`, nil},
+		{"number accepts integer", `{"type": "number"}`, `1`, nil},
+		{"integer rejects fraction", `{"type": "integer"}`, `1.5`, []string{": expected integer, got number"}},
+		{"required", `{"required": ["a", "b"]}`, `{"a": 1}`, []string{": missing required key b"}},
+		{"enum", `{"enum": ["a", 1, [true]]}`, `[true]`, nil},
+		{"const", `{"const": {"a": 1}}`, `{"a": 2}`, []string{": must be map[a:1]"}},
+		{"string limits", `{"minLength": 2, "maxLength": 3, "pattern": "^[a-z]+$"}`, `"äöüß"`, []string{": must be at most 3 characters long", ": must match ^[a-z]+$"}},
+		{"number limits", `{"minimum": 1, "exclusiveMaximum": 10, "multipleOf": 3}`, `10`, []string{": must be < 10", ": must be a multiple of 3"}},
+		{"boolean schema", `{"properties": {"a": true, "b": false}}`, `{"a": 1, "b": 2}`, []string{"/b: no value allowed"}},
+		{"ref", `{"$defs": {"port": {"type": "integer", "maximum": 65535}}, "properties": {"port": {"$ref": "#/$defs/port"}}}`, `{"port": 70000}`, []string{"/port: must be <= 65535"}},
+		{"escaped ref", `{"$defs": {"a/b": {"type": "string"}}, "items": {"$ref": "#/$defs/a~1b"}}`, `["x", 1]`, []string{"/1: expected string, got integer"}},
+		{"recursive ref", `{"properties": {"name": {"type": "string"}, "children": {"items": {"$ref": "#"}}}}`, `{"children": [{"children": [{"name": 1}]}]}`, []string{"/children/0/children/0/name: expected string, got integer"}},
+		{"unresolvable ref", `{"$ref": "#/$defs/missing"}`, `1`, []string{": can't resolve reference #/$defs/missing"}},
+		{"remote ref", `{"$ref": "https://example.com/schema.json"}`, `1`, []string{": unsupported reference https://example.com/schema.json"}},
+		{"oneOf", `{"oneOf": [{"type": "string"}, {"type": "integer"}]}`, `1`, nil},
+		{"oneOf none", `{"oneOf": [{"type": "string"}, {"type": "boolean"}]}`, `1`, []string{": must match exactly one schema of oneOf, matches 0"}},
+		{"oneOf several", `{"oneOf": [{"type": "number"}, {"type": "integer"}]}`, `1`, []string{": must match exactly one schema of oneOf, matches 2"}},
+		{"oneOf with refs", `{"$defs": {"a": {"required": ["a"]}, "b": {"required": ["b"]}}, "oneOf": [{"$ref": "#/$defs/a"}, {"$ref": "#/$defs/b"}]}`, `{"a": 1, "b": 2}`, []string{": must match exactly one schema of oneOf, matches 2"}},
+		{"anyOf", `{"anyOf": [{"type": "string"}, {"minimum": 5}]}`, `3`, []string{": must match at least one schema of anyOf"}},
+		{"allOf", `{"allOf": [{"minimum": 5}, {"maximum": 1}]}`, `3`, []string{": must be >= 5", ": must be <= 1"}},
+		{"not", `{"not": {"type": "null"}}`, `null`, []string{": must not match the schema of not"}},
+		{"if then", `{"if": {"properties": {"tls": {"const": true}}}, "then": {"required": ["cert"]}, "else": {"maxProperties": 1}}`, `{"tls": true}`, []string{": missing required key cert"}},
+		{"if else", `{"if": {"properties": {"tls": {"const": true}}}, "then": {"required": ["cert"]}, "else": {"maxProperties": 1}}`, `{"tls": false, "a": 1}`, []string{": must have at most 1 keys"}},
+		{"additional properties", `{"properties": {"a": {}}, "patternProperties": {"^x-": {"type": "string"}}, "additionalProperties": false}`, `{"a": 1, "x-b": "c", "d/e": 2}`, []string{"/d~1e: unknown key d/e"}},
+		{"additional properties schema", `{"additionalProperties": {"type": "integer"}}`, `{"a": 1, "b": "2"}`, []string{"/b: expected integer, got string"}},
+		{"arrays", `{"prefixItems": [{"type": "string"}], "items": {"type": "integer"}, "minItems": 4, "uniqueItems": true}`, `["a", 1, 1]`, []string{": must have at least 4 items", ": items 1 and 2 are equal"}},
+		{"contains", `{"contains": {"const": 2}}`, `[1, 3]`, []string{": no item matches the schema of contains"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			validator, err := newSchemaValidator([]byte(test.schema))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var instance interface{}
+			if err := json.Unmarshal([]byte(test.instance), &instance); err != nil {
+				t.Fatalf("invalid instance: %v", err)
+			}
+
+			var got []string
+			for _, violation := range validator.validate(validator.root, instance, "") {
+				got = append(got, violation.Pointer+": "+violation.Message)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSchemaValidatorLocatesViolations(t *testing.T) {
+	validator, err := newSchemaValidator([]byte(`{"properties": {"db": {"properties": {"port": {"type": "integer"}}}}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data := []byte("db:\n  host: h\n  port: abc\n")
+	document, _, err := decodeDocument(FormatYAML, data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err = validator.validateDocument("config.yml", document, func(path []string) (int, int, bool) {
+		return locateYAML(data, path)
+	})
+
+	schemaError, ok := err.(*SchemaError)
+	if !ok || len(schemaError.Violations) != 1 {
+		t.Fatalf("got %v, want a single violation", err)
+	}
+	if violation := schemaError.Violations[0]; violation.Pointer != "/db/port" || violation.Line != 3 || violation.Column != 3 {
+		t.Errorf("got %+v, want /db/port at 3:3", violation)
+	}
+}
+
+func TestNewSchemaValidatorInvalidSchema(t *testing.T) {
+	if _, err := newSchemaValidator([]byte(`{"type": `)); err == nil {
+		t.Errorf("expected an error for an invalid schema")
+	}
+}
+
+func TestLoadValidatesSchema(t *testing.T) {
+	type appConfig struct {
+		Name string
+		Port int
+		DB   struct {
+			Host string
+		}
+	}
+	schema := []byte(`{
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"port": {"type": "integer", "maximum": 65535},
+			"db": {"required": ["host"]}
+		}
+	}`)
+
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml":            "name: app\nport: 80\ndb:\n  host: h\n",
+		"config.production.yml": "port: 70000\n",
+		"config.staging.yml":    "name: staging\n",
+		"invalid.json":          "{\n  \"name\": \"\",\n  \"db\": {}\n}\n",
+		"invalid.toml":          "name = \"app\"\nport = \"80\"\n",
+	})
+
+	var config appConfig
+	if err := New(&Config{Silent: true, Schema: schema, Environment: "staging"}).Load(&config, filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "staging" || config.Port != 80 {
+		t.Errorf("got %+v", config)
+	}
+
+	// each file is validated on its own, before it is merged
+	config = appConfig{}
+	err := New(&Config{Silent: true, Schema: schema, Environment: "production"}).Load(&config, filepath.Join(dir, "config.yml"))
+	schemaError, ok := err.(*SchemaError)
+	if !ok || schemaError.File != filepath.Join(dir, "config.production.yml") {
+		t.Fatalf("got %v, want a SchemaError for config.production.yml", err)
+	}
+	if want := "config.production.yml:1:1: /port: must be <= 65535"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+	if config.Port == 70000 {
+		t.Errorf("got %+v, want the invalid file not to be decoded", config)
+	}
+
+	tests := []struct {
+		file string
+		want []string
+	}{
+		{"invalid.json", []string{"invalid.json:2:3: /name: must be at least 1 characters long", "invalid.json:3:3: /db: missing required key host"}},
+		{"invalid.toml", []string{"invalid.toml:2:1: /port: expected integer, got string"}},
+	}
+	for _, test := range tests {
+		err := New(&Config{Silent: true, Schema: schema}).Load(&appConfig{}, filepath.Join(dir, test.file))
+		for _, want := range test.want {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%v: got %v, want %v", test.file, err, want)
+			}
+		}
+	}
+
+	if err := New(&Config{Schema: []byte(`{"type": `)}).Load(&appConfig{}, filepath.Join(dir, "config.yml")); err == nil {
+		t.Error("expected an error for an invalid schema")
+	}
+}
@@ -0,0 +1,111 @@
+package configService
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type bytesConfig struct {
+	Name string `default:"app"`
+	Port int
+	DB   struct {
+		Host string
+	}
+}
+
+func TestLoadBytes(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format Format
+		want   bytesConfig
+		err    string
+	}{
+		{"yaml", "port: 1\ndb:\n  host: h\n", FormatYAML, bytesConfig{Name: "app", Port: 1, DB: struct{ Host string }{"h"}}, ""},
+		{"json", `{"Name": "json", "Port": 2}`, FormatJSON, bytesConfig{Name: "json", Port: 2}, ""},
+		{"toml", "Port = 3\n[DB]\nHost = \"h\"\n", FormatTOML, bytesConfig{Name: "app", Port: 3, DB: struct{ Host string }{"h"}}, ""},
+		{"detected json", `{"Port": 4}`, "", bytesConfig{Name: "app", Port: 4}, ""},
+		{"detected toml", "Port = 5\n", "", bytesConfig{Name: "app", Port: 5}, ""},
+		{"detected yaml", "# comment\nport: 6\n", "", bytesConfig{Name: "app", Port: 6}, ""},
+		{"empty", "", "", bytesConfig{Name: "app"}, ""},
+		{"comments only", "# nothing\n", "", bytesConfig{Name: "app"}, ""},
+		{"undetectable", "\x00\x01", "", bytesConfig{}, "failed to decode config"},
+		{"unknown format", "port: 1\n", "cue", bytesConfig{}, "unknown format cue"},
+		{"explicit format wins", "Port = 7\n", FormatYAML, bytesConfig{}, "yaml"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var config bytesConfig
+			err := New(&Config{Silent: true}).LoadBytes(&config, []byte(test.data), test.format)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.want) {
+				t.Errorf("got %+v, want %+v", config, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadBytesLikeLoad(t *testing.T) {
+	// unknown keys are located without a file name
+	err := New(&Config{Silent: true, ErrorOnUnmatchedKeys: true}).LoadBytes(&bytesConfig{}, []byte("port: 1\nhots: h\n"), FormatYAML)
+	if unknownErr, ok := err.(*UnknownKeysError); !ok || len(unknownErr.Keys) != 1 || unknownErr.Keys[0] != (UnknownKey{Key: "hots", Line: 2, Column: 1}) {
+		t.Errorf("got %#v, want an UnknownKeysError for hots", err)
+	}
+
+	// the environment overrides the data
+	setTestEnv(t, map[string]string{"BYTESAPP_PORT": "9"})
+	var config bytesConfig
+	if err := New(&Config{Silent: true, ENVPrefix: "BytesApp"}).LoadBytes(&config, []byte("port: 1\n"), FormatYAML); err != nil {
+		t.Fatal(err)
+	}
+	if config.Port != 9 {
+		t.Errorf("got %+v, want the port of the environment", config)
+	}
+
+	if err := New(nil).LoadBytes(bytesConfig{}, nil, FormatYAML); err == nil {
+		t.Error("expected an error for a config which isn't addressable")
+	}
+}
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
+
+func TestLoadReader(t *testing.T) {
+	var config bytesConfig
+	if err := New(&Config{Silent: true}).LoadReader(&config, strings.NewReader("port = 1\n"), FormatTOML); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" || config.Port != 1 {
+		t.Errorf("got %+v", config)
+	}
+
+	if err := New(nil).LoadReader(&bytesConfig{}, failingReader{}, FormatYAML); err == nil || err.Error() != "read failed" {
+		t.Errorf("got %v, want the error of the reader", err)
+	}
+
+	file, err := os.Open(filepath.Join(writeTestFiles(t, map[string]string{"config.json": `{"Port": 2}`}), "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	config = bytesConfig{}
+	if err := New(nil).LoadReader(&config, file, ""); err != nil || config.Port != 2 {
+		t.Errorf("got %+v and %v", config, err)
+	}
+}
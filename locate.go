@@ -0,0 +1,274 @@
+package configService
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// locateKey returns the line and column (both starting at 1) of the value at
+// the given key path inside a configuration file and whether the path exists.
+// If it doesn't, the position of its longest existing prefix is returned, or
+// 0, 0 if the format isn't supported.
+func locateKey(data []byte, format Format, path []string) (int, int, bool) {
+	if registered := lookupFormat(format); registered != nil && registered.locate != nil {
+		return registered.locate(data, path)
+	}
+	return 0, 0, false
+}
+
+// offsetPosition converts a byte offset into a line and column
+func offsetPosition(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	line := bytes.Count(data[:offset], []byte("\n")) + 1
+	column := offset - bytes.LastIndexByte(data[:offset], '\n')
+	return line, column
+}
+
+// splitPointer splits a JSON pointer into its unescaped reference tokens
+func splitPointer(pointer string) []string {
+	if pointer == "" || pointer == "/" {
+		return nil
+	}
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens
+}
+
+func locateJSON(data []byte, path []string) (int, int, bool) {
+	locator := &jsonLocator{data: data}
+	depth := locator.locate(path)
+	line, column := offsetPosition(data, locator.found)
+	return line, column, depth == len(path)
+}
+
+func locateYAML(data []byte, path []string) (int, int, bool) {
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		return 0, 0, false
+	}
+
+	node := document.Content[0]
+	line, column, depth := node.Line, node.Column, 0
+	for _, segment := range path {
+		for node.Kind == yamlv3.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+
+		var next *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == segment {
+					line, column = node.Content[i].Line, node.Content[i].Column
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yamlv3.SequenceNode:
+			if index, err := strconv.Atoi(segment); err == nil && index >= 0 && index < len(node.Content) {
+				next = node.Content[index]
+				line, column = next.Line, next.Column
+			}
+		}
+
+		if next == nil {
+			break
+		}
+		node = next
+		depth++
+	}
+	return line, column, depth == len(path)
+}
+
+var (
+	tomlTableRegexp = regexp.MustCompile(`^\s*(\[\[?)\s*([^\]]+?)\s*\]\]?`)
+	tomlKeyRegexp   = regexp.MustCompile(`^(\s*)("[^"]*"|'[^']*'|[A-Za-z0-9_.\-"' ]+?)\s*=`)
+)
+
+func locateTOML(data []byte, path []string) (int, int, bool) {
+	var (
+		bestLine, bestColumn, bestLength int
+		table                            []string
+		arrayIndexes                     = map[string]int{}
+	)
+
+	for i, line := range strings.Split(string(data), "\n") {
+		var keyPath []string
+		column := 1
+
+		if match := tomlTableRegexp.FindStringSubmatch(line); match != nil {
+			table = splitTOMLKey(match[2])
+			if match[1] == "[[" {
+				name := strings.Join(table, ".")
+				index, ok := arrayIndexes[name]
+				if ok {
+					index++
+				}
+				arrayIndexes[name] = index
+				table = append(table, strconv.Itoa(index))
+			}
+			keyPath = table
+			column = strings.Index(line, "[") + 1
+		} else if match := tomlKeyRegexp.FindStringSubmatch(line); match != nil {
+			keyPath = append(append([]string{}, table...), splitTOMLKey(match[2])...)
+			column = len(match[1]) + 1
+		} else {
+			continue
+		}
+
+		length := commonPrefixLength(keyPath, path)
+		if length == len(keyPath) && length > bestLength {
+			bestLine, bestColumn, bestLength = i+1, column, length
+		}
+	}
+	return bestLine, bestColumn, bestLength == len(path)
+}
+
+func splitTOMLKey(key string) []string {
+	var parts []string
+	for _, part := range strings.Split(key, ".") {
+		parts = append(parts, strings.Trim(strings.TrimSpace(part), `"'`))
+	}
+	return parts
+}
+
+func commonPrefixLength(a, b []string) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// jsonLocator finds the offset of a value inside a JSON document
+type jsonLocator struct {
+	data  []byte
+	pos   int
+	found int
+}
+
+// locate searches the value at path and returns the number of path segments
+// which were found
+func (locator *jsonLocator) locate(path []string) int {
+	locator.skipSpace()
+	locator.found = locator.pos
+	if len(path) == 0 || locator.pos >= len(locator.data) {
+		return 0
+	}
+
+	switch locator.data[locator.pos] {
+	case '{':
+		locator.pos++
+		for locator.skipSpace(); locator.pos < len(locator.data) && locator.data[locator.pos] != '}'; locator.skipSpace() {
+			keyStart := locator.pos
+			key := locator.readString()
+			locator.skipSpace()
+			if locator.pos < len(locator.data) && locator.data[locator.pos] == ':' {
+				locator.pos++
+			}
+			if key == path[0] {
+				depth := locator.locate(path[1:])
+				if len(path) == 1 {
+					locator.found = keyStart
+				}
+				return depth + 1
+			}
+			locator.skipValue()
+			locator.skipSpace()
+			if locator.pos < len(locator.data) && locator.data[locator.pos] == ',' {
+				locator.pos++
+			}
+		}
+	case '[':
+		index, err := strconv.Atoi(path[0])
+		if err != nil {
+			return 0
+		}
+		locator.pos++
+		for i := 0; ; i++ {
+			locator.skipSpace()
+			if locator.pos >= len(locator.data) || locator.data[locator.pos] == ']' {
+				return 0
+			}
+			if i == index {
+				return locator.locate(path[1:]) + 1
+			}
+			locator.skipValue()
+			locator.skipSpace()
+			if locator.pos < len(locator.data) && locator.data[locator.pos] == ',' {
+				locator.pos++
+			}
+		}
+	}
+	return 0
+}
+
+func (locator *jsonLocator) skipSpace() {
+	for locator.pos < len(locator.data) {
+		switch locator.data[locator.pos] {
+		case ' ', '\t', '\r', '\n':
+			locator.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (locator *jsonLocator) readString() string {
+	start := locator.pos
+	locator.skipValue()
+	var s string
+	json.Unmarshal(locator.data[start:locator.pos], &s)
+	return s
+}
+
+func (locator *jsonLocator) skipValue() {
+	if locator.pos >= len(locator.data) {
+		return
+	}
+
+	switch locator.data[locator.pos] {
+	case '"':
+		for locator.pos++; locator.pos < len(locator.data); locator.pos++ {
+			switch locator.data[locator.pos] {
+			case '\\':
+				locator.pos++
+			case '"':
+				locator.pos++
+				return
+			}
+		}
+	case '{', '[':
+		depth := 0
+		for ; locator.pos < len(locator.data); locator.pos++ {
+			switch locator.data[locator.pos] {
+			case '"':
+				locator.skipValue()
+				locator.pos--
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					locator.pos++
+					return
+				}
+			}
+		}
+	default:
+		for ; locator.pos < len(locator.data); locator.pos++ {
+			switch locator.data[locator.pos] {
+			case ',', '}', ']', ' ', '\t', '\r', '\n':
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package configService
+
+import (
+	"reflect"
+	"strings"
+)
+
+// unsetMarker is the value which clears a key set by an earlier configuration
+// file. A null value has the same effect.
+const unsetMarker = "$unset"
+
+// Merge strategies selected with the merge tag, e.g. merge:"append". They
+// define how the value of a field is combined with the value set by earlier
+// configuration files.
+const (
+	// MergeReplace replaces slices and maps. It is the default for slices.
+	MergeReplace = "replace"
+	// MergeAppend appends the items of a slice to the earlier ones
+	MergeAppend = "append"
+	// MergeUnique appends the items of a slice which aren't contained yet
+	MergeUnique = "unique"
+	// MergeByKey merges slice items whose field of the given name is equal,
+	// e.g. merge:"merge-by-key:name", and appends all others
+	MergeByKey = "merge-by-key"
+)
+
+// mergeOperation is applied to the field at keys around decoding a file
+type mergeOperation struct {
+	keys     []string
+	strategy string
+	mergeKey string
+	items    []interface{}
+	previous reflect.Value
+}
+
+func isUnset(value interface{}) bool {
+	return value == nil || value == unsetMarker
+}
+
+// collectMergeOperations walks the document alongside the config type and
+// returns the operations required by unset markers and merge tags. Keys are
+// matched to fields like the decoder of the format does. Unset keys are
+// removed from the document, in which case modified is true.
+func collectMergeOperations(t reflect.Type, document map[string]interface{}, keys []string, format Format) (operations []mergeOperation, modified bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for key, value := range document {
+		fieldKeys := append(append([]string{}, keys...), key)
+
+		var fieldType reflect.Type
+		var fieldStruct reflect.StructField
+		switch t.Kind() {
+		case reflect.Map:
+			fieldType = t.Elem()
+		case reflect.Struct:
+			var ok bool
+			if fieldStruct, ok = decoderField(t, key, format); !ok {
+				// left for the unknown keys check
+				continue
+			}
+			fieldType = fieldStruct.Type
+		default:
+			continue
+		}
+
+		if isUnset(value) {
+			delete(document, key)
+			modified = true
+			operations = append(operations, mergeOperation{keys: fieldKeys, strategy: unsetMarker})
+			continue
+		}
+
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			strategy := fieldStruct.Tag.Get("merge")
+			mergeKey := ""
+			if strings.HasPrefix(strategy, MergeByKey+":") {
+				mergeKey = strings.TrimPrefix(strategy, MergeByKey+":")
+				strategy = MergeByKey
+			}
+
+			switch {
+			case fieldType.Kind() == reflect.Slice && strategy != "" && strategy != MergeReplace:
+				items, _ := value.([]interface{})
+				operations = append(operations, mergeOperation{keys: fieldKeys, strategy: strategy, mergeKey: mergeKey, items: items})
+			case fieldType.Kind() == reflect.Map && strategy == MergeReplace:
+				operations = append(operations, mergeOperation{keys: fieldKeys, strategy: strategy})
+			}
+		}
+
+		if subDocument, ok := value.(map[string]interface{}); ok {
+			subOperations, subModified := collectMergeOperations(fieldType, subDocument, fieldKeys, format)
+			operations = append(operations, subOperations...)
+			modified = modified || subModified
+		}
+	}
+	return operations, modified
+}
+
+// prepareMerge is called before a file is decoded into config. It keeps the
+// values which are combined with the decoded ones and clears replaced maps.
+func prepareMerge(config interface{}, operations []mergeOperation) {
+	for i, operation := range operations {
+		field, err := lookupPath(reflect.ValueOf(config), operation.keys)
+		if err != nil {
+			continue
+		}
+
+		switch operation.strategy {
+		case MergeReplace:
+			if field.CanSet() {
+				field.Set(reflect.Zero(field.Type()))
+			}
+		case MergeAppend, MergeUnique, MergeByKey:
+			operations[i].previous = copyValue(field)
+		}
+	}
+}
+
+// applyMerge is called after a file is decoded into config and combines the
+// decoded values with the earlier ones
+func applyMerge(config interface{}, operations []mergeOperation) {
+	for _, operation := range operations {
+		if operation.strategy == unsetMarker {
+			unsetPath(config, operation.keys)
+			continue
+		}
+
+		field, err := lookupPath(reflect.ValueOf(config), operation.keys)
+		if err != nil || !field.CanSet() || !operation.previous.IsValid() {
+			continue
+		}
+
+		sliceField := field
+		previous := operation.previous
+		for sliceField.Kind() == reflect.Ptr {
+			if sliceField.IsNil() || previous.IsNil() {
+				break
+			}
+			sliceField, previous = sliceField.Elem(), previous.Elem()
+		}
+		if sliceField.Kind() != reflect.Slice || previous.Kind() != reflect.Slice {
+			continue
+		}
+
+		switch operation.strategy {
+		case MergeAppend:
+			sliceField.Set(reflect.AppendSlice(previous, sliceField))
+		case MergeUnique:
+			result := previous
+			for i := 0; i < sliceField.Len(); i++ {
+				if indexOfValue(result, sliceField.Index(i)) < 0 {
+					result = reflect.Append(result, sliceField.Index(i))
+				}
+			}
+			sliceField.Set(result)
+		case MergeByKey:
+			sliceField.Set(mergeSliceByKey(previous, sliceField, operation.mergeKey, operation.items))
+		}
+	}
+}
+
+// unsetPath resets the struct field or removes the map entry at keys
+func unsetPath(config interface{}, keys []string) {
+	parent, err := lookupPath(reflect.ValueOf(config), keys[:len(keys)-1])
+	if err != nil {
+		return
+	}
+	for parent.Kind() == reflect.Ptr || parent.Kind() == reflect.Interface {
+		if parent.IsNil() {
+			return
+		}
+		parent = parent.Elem()
+	}
+
+	key := keys[len(keys)-1]
+	switch parent.Kind() {
+	case reflect.Struct:
+		if field, ok := structField(parent, key, false); ok && field.CanSet() {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	case reflect.Map:
+		if mapKey, err := mapKey(parent.Type(), key); err == nil && !parent.IsNil() {
+			parent.SetMapIndex(mapKey, reflect.Value{})
+		}
+	}
+}
+
+func indexOfValue(slice, value reflect.Value) int {
+	for i := 0; i < slice.Len(); i++ {
+		if reflect.DeepEqual(slice.Index(i).Interface(), value.Interface()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeSliceByKey merges the items of current into those of previous which
+// have the same value in the field named key. Only the keys set in the file,
+// given by items, override the fields of earlier items.
+func mergeSliceByKey(previous, current reflect.Value, key string, items []interface{}) reflect.Value {
+	result := previous
+	for i := 0; i < current.Len(); i++ {
+		elem := current.Index(i)
+
+		index := -1
+		if elemKey, ok := elementKey(elem, key); ok {
+			for j := 0; j < result.Len(); j++ {
+				if otherKey, ok := elementKey(result.Index(j), key); ok && reflect.DeepEqual(elemKey, otherKey) {
+					index = j
+					break
+				}
+			}
+		}
+
+		var item map[string]interface{}
+		if i < len(items) {
+			item, _ = items[i].(map[string]interface{})
+		}
+
+		if index < 0 || item == nil {
+			result = reflect.Append(result, elem)
+			continue
+		}
+		mergeElement(result.Index(index), elem, item)
+	}
+	return result
+}
+
+func elementKey(elem reflect.Value, key string) (interface{}, bool) {
+	value, err := lookupPath(elem, []string{key})
+	if err != nil {
+		return nil, false
+	}
+	return value.Interface(), true
+}
+
+// mergeElement copies the fields or entries of source named by the keys of
+// item to target
+func mergeElement(target, source reflect.Value, item map[string]interface{}) {
+	for target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(source)
+			return
+		}
+		target, source = target.Elem(), source.Elem()
+	}
+
+	for key := range item {
+		switch target.Kind() {
+		case reflect.Struct:
+			targetField, ok := structField(target, key, true)
+			sourceField, ok2 := structField(source, key, false)
+			if ok && ok2 && targetField.CanSet() {
+				targetField.Set(sourceField)
+			}
+		case reflect.Map:
+			if mapKey, err := mapKey(target.Type(), key); err == nil && !target.IsNil() {
+				target.SetMapIndex(mapKey, source.MapIndex(mapKey))
+			}
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package configService
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mergeUser struct {
+	Name  string
+	Role  string
+	Admin bool
+}
+
+type mergeConfig struct {
+	Name   string
+	Tags   []string       `merge:"append"`
+	Labels []string       `json:"names" merge:"unique"`
+	Users  []mergeUser    `merge:"merge-by-key:name"`
+	Limits map[string]int `merge:"replace"`
+	Hosts  map[string]string
+}
+
+func TestMergeStrategies(t *testing.T) {
+	tests := []struct {
+		name      string
+		extension string
+		base      string
+		override  string
+		want      mergeConfig
+	}{
+		{
+			"append", ".yml",
+			"tags: [a, b]\n", "tags: [b, c]\n",
+			mergeConfig{Tags: []string{"a", "b", "b", "c"}},
+		},
+		{
+			"unique", ".yml",
+			"labels: [a, b]\n", "labels: [b, c]\n",
+			mergeConfig{Labels: []string{"a", "b", "c"}},
+		},
+		{
+			"unique by json tag", ".json",
+			`{"names": ["a", "b"]}`, `{"names": ["b", "c"]}`,
+			mergeConfig{Labels: []string{"a", "b", "c"}},
+		},
+		{
+			"merge by key", ".yml",
+			"users:\n  - {name: a, role: dev}\n  - {name: b}\n", "users:\n  - {name: a, admin: true}\n  - {name: c}\n",
+			mergeConfig{Users: []mergeUser{{Name: "a", Role: "dev", Admin: true}, {Name: "b"}, {Name: "c"}}},
+		},
+		{
+			"replace map", ".yml",
+			"limits: {a: 1, b: 2}\n", "limits: {c: 3}\n",
+			mergeConfig{Limits: map[string]int{"c": 3}},
+		},
+		{
+			"unset field", ".yml",
+			"name: app\ntags: [a]\n", "name: $unset\ntags: ~\n",
+			mergeConfig{},
+		},
+		{
+			"unset map entry", ".toml",
+			"[hosts]\na = \"x\"\nb = \"y\"\n", "[hosts]\na = \"$unset\"\n",
+			mergeConfig{Hosts: map[string]string{"b": "y"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			extension := test.extension
+			dir := writeTestFiles(t, map[string]string{
+				"config" + extension:   test.override,
+				"defaults" + extension: test.base,
+			})
+
+			// Load applies the first file last
+			var config mergeConfig
+			err := New(&Config{Silent: true}).Load(&config, filepath.Join(dir, "config"+extension), filepath.Join(dir, "defaults"+extension))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config, test.want) {
+				t.Errorf("got %+v, want %+v", config, test.want)
+			}
+		})
+	}
+}
+
+func TestMergeKeepsUnknownUnsetKeys(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "name: app\nnmae: $unset\n"})
+
+	err := New(&Config{ErrorOnUnmatchedKeys: true}).Load(&mergeConfig{}, filepath.Join(dir, "config.yml"))
+	if _, ok := err.(*UnknownKeysError); !ok {
+		t.Errorf("got %v, want an UnknownKeysError for nmae", err)
+	}
+}
+
+func TestReloadDoesNotGrowMergedSlices(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml":   "tags: [b]\nlabels: [b]\n",
+		"defaults.yml": "tags: [a]\nlabels: [a]\n",
+	})
+	file := filepath.Join(dir, "config.yml")
+
+	reloaded := make(chan struct{}, 1)
+	service := New(&Config{
+		Silent:             true,
+		AutoReload:         true,
+		AutoReloadInterval: 10 * time.Millisecond,
+		AutoReloadCallback: func(interface{}) {
+			select {
+			case reloaded <- struct{}{}:
+			default:
+			}
+		},
+	})
+	config := &mergeConfig{Tags: []string{"initial"}}
+	if err := service.Load(config, file, filepath.Join(dir, "defaults.yml")); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		modTime := time.Now().Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-reloaded:
+		case <-time.After(5 * time.Second):
+			t.Fatal("config wasn't reloaded")
+		}
+	}
+
+	want := mergeConfig{Tags: []string{"initial", "a", "b"}, Labels: []string{"a", "b"}}
+	if !reflect.DeepEqual(*config, want) {
+		t.Errorf("got %+v after reloading, want %+v", *config, want)
+	}
+}
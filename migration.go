@@ -0,0 +1,99 @@
+package configService
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+)
+
+// Migration migrates the document of a configuration file from one version
+// to the next one. The document holds the keys of the file as generic maps,
+// slices and values, which are modified in place, e.g. to rename a key:
+//
+//	if value, ok := document["db"]; ok {
+//		document["database"] = value
+//		delete(document, "db")
+//	}
+type Migration func(document map[string]interface{}) error
+
+// lookupMigration returns the migration from version to the next one, or
+// nil if there is none
+func (configService *ConfigService) lookupMigration(version int) Migration {
+	return configService.Config.Migrations[version]
+}
+
+func (configService *ConfigService) hasMigrations() bool {
+	return len(configService.Config.Migrations) > 0
+}
+
+// GetVersionKey returns the key holding the version of configuration files
+func (configService *ConfigService) GetVersionKey() string {
+	if configService.Config.VersionKey == "" {
+		return "version"
+	}
+	return configService.Config.VersionKey
+}
+
+// migrateDocument runs the Migrations for the version of the document of
+// file. It returns whether the document was migrated.
+func (configService *ConfigService) migrateDocument(file string, document interface{}) (bool, error) {
+	documentMap, ok := document.(map[string]interface{})
+	if !ok || !configService.hasMigrations() {
+		return false, nil
+	}
+
+	versionKey := configService.GetVersionKey()
+	value, ok := documentMap[versionKey]
+	if !ok || value == nil {
+		return false, nil
+	}
+	version, err := strconv.Atoi(toString(value))
+	if err != nil {
+		return false, fmt.Errorf("%v: invalid %v %v", file, versionKey, value)
+	}
+
+	migrated := false
+	for migration := configService.lookupMigration(version); migration != nil; migration = configService.lookupMigration(version) {
+		if configService.Config.Debug || configService.Config.Verbose {
+			configService.logf("Migrating configuration %v from version %v to %v\n", file, version, version+1)
+		}
+		if err := migration(documentMap); err != nil {
+			return false, fmt.Errorf("failed to migrate %v from version %v to %v: %v", file, version, version+1, err)
+		}
+		version++
+		documentMap[versionKey] = version
+		migrated = true
+	}
+	return migrated, nil
+}
+
+// MigrateFile runs the Migrations on a configuration file and writes it back
+// if it was migrated. Comments and the order of keys of the file are lost.
+// It returns whether the file was migrated.
+func (configService *ConfigService) MigrateFile(file string) (bool, error) {
+	fileInfo, err := os.Stat(file)
+	if err != nil {
+		return false, err
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false, err
+	}
+
+	document, format, err := decodeDocument(configService.dataFormat(fileFormat(file), data), data)
+	if err != nil {
+		return false, fmt.Errorf("failed to migrate %v: %v", file, err)
+	}
+	if migrated, err := configService.migrateDocument(file, document); err != nil || !migrated {
+		return false, err
+	}
+
+	if data, err = encodeDocument(format, document); err != nil {
+		return false, fmt.Errorf("failed to migrate %v: %v", file, err)
+	}
+	if err := ioutil.WriteFile(file, data, fileInfo.Mode().Perm()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
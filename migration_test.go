@@ -0,0 +1,142 @@
+package configService
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type migrationConfig struct {
+	Version  int
+	Database struct {
+		Host string
+		Port int
+	}
+}
+
+// testMigrations renames db to database (1 -> 2) and its address to host
+// (2 -> 3)
+var testMigrations = map[int]Migration{
+	1: func(document map[string]interface{}) error {
+		if value, ok := document["db"]; ok {
+			document["database"] = value
+			delete(document, "db")
+		}
+		return nil
+	},
+	2: func(document map[string]interface{}) error {
+		if database, ok := document["database"].(map[string]interface{}); ok {
+			database["host"] = database["address"]
+			delete(database, "address")
+		}
+		return nil
+	},
+}
+
+func TestMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		version int
+		host    string
+		err     string
+	}{
+		{"chained", "version: 1\ndb:\n  address: a\n", 3, "a", ""},
+		{"from the middle", "version: 2\ndatabase:\n  address: b\n", 3, "b", ""},
+		{"current version", "version: 3\ndatabase:\n  host: c\n", 3, "c", ""},
+		{"without version", "db:\n  address: d\n", 0, "", ""},
+		{"version as string", "version: \"1\"\ndb:\n  address: e\n", 3, "e", ""},
+		{"invalid version", "version: one\n", 0, "", "invalid version one"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTestFiles(t, map[string]string{"config.yml": test.data})
+			var config migrationConfig
+			err := New(&Config{Silent: true, Migrations: testMigrations}).Load(&config, filepath.Join(dir, "config.yml"))
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("got %v, want %v", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Version != test.version || config.Database.Host != test.host {
+				t.Errorf("got %+v, want version %v and host %q", config, test.version, test.host)
+			}
+		})
+	}
+}
+
+func TestMigrationsPerService(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "version: 1\ndb:\n  address: a\n"})
+	file := filepath.Join(dir, "config.yml")
+
+	// a service without migrations loads the file as it is and reports the
+	// old keys
+	var config migrationConfig
+	err := New(&Config{ErrorOnUnmatchedKeys: true}).Load(&config, file)
+	if _, ok := err.(*UnknownKeysError); !ok {
+		t.Errorf("got %v, want an UnknownKeysError for db", err)
+	}
+
+	// the version key is read by migrations and is known with them
+	type unversioned struct {
+		Database struct {
+			Host string
+		}
+	}
+	if err := New(&Config{ErrorOnUnmatchedKeys: true, Migrations: testMigrations}).Load(&unversioned{}, file); err != nil {
+		t.Errorf("got %v, want the version key to be known", err)
+	}
+
+	failing := map[int]Migration{1: func(map[string]interface{}) error { return errors.New("broken") }}
+	err = New(&Config{Silent: true, Migrations: failing}).Load(&config, file)
+	if err == nil || !strings.Contains(err.Error(), "from version 1 to 2: broken") {
+		t.Errorf("got %v, want the failing migration", err)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.yml":  "version: 1\ndb:\n  address: a\n",
+		"current.yml": "version: 3\n# kept\ndatabase:\n  host: c\n",
+	})
+
+	migrated, err := MigrateFile(filepath.Join(dir, "config.yml"), testMigrations)
+	if err != nil || !migrated {
+		t.Fatalf("got %v and %v", migrated, err)
+	}
+	data, _ := ioutil.ReadFile(filepath.Join(dir, "config.yml"))
+	if string(data) != "database:\n  host: a\nversion: 3\n" {
+		t.Errorf("got\n%s", data)
+	}
+
+	migrated, err = MigrateFile(filepath.Join(dir, "current.yml"), testMigrations)
+	data, _ = ioutil.ReadFile(filepath.Join(dir, "current.yml"))
+	if err != nil || migrated || !strings.Contains(string(data), "# kept") {
+		t.Errorf("got %v, %v and\n%s, want current files to be left alone", migrated, err, data)
+	}
+}
+
+func TestSetupConfigPersistMigrations(t *testing.T) {
+	for _, persist := range []bool{false, true} {
+		dir := writeTestFiles(t, map[string]string{"config.yml": "version: 1\ndb:\n  address: a\n"})
+		file := filepath.Join(dir, "config.yml")
+
+		service := New(&Config{Silent: true, Migrations: testMigrations, PersistMigrations: persist})
+		created, err := service.SetupConfig(&migrationConfig{}, file, func(config interface{}) interface{} { return config })
+		if err != nil || created {
+			t.Fatalf("got %v and %v, want the existing file to be kept", created, err)
+		}
+
+		data, _ := ioutil.ReadFile(file)
+		if written := strings.Contains(string(data), "version: 3"); written != persist {
+			t.Errorf("PersistMigrations %v: got\n%s", persist, data)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package configService
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// keyTags are the struct tags whose names are honoured when a config is
+// addressed by key, e.g. by Get and Set
+var keyTags = []string{"yaml", "json", "toml", "hcl"}
+
+// Get returns the value at the given dotted path of config, e.g.
+// "db.replicas.0.host". Path segments are matched against the yaml, json,
+// toml and hcl tag names of struct fields or, case insensitive, their field
+// names. Unexported fields and fields ignored by a tag, e.g. `yaml:"-"`,
+// can't be addressed. Slices and arrays are indexed by number, maps by their
+// key.
+func Get(config interface{}, path string) (interface{}, error) {
+	value, err := lookupPath(reflect.ValueOf(config), splitPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return value.Interface(), nil
+}
+
+// Set sets the value at the given dotted path of config. Nil pointers and maps
+// on the way are allocated and a slice grows by one if the index equals its
+// length. A string value is parsed like an environment variable if the target
+// isn't a string.
+func Set(config interface{}, path string, value interface{}) error {
+	configValue := reflect.ValueOf(config)
+	if configValue.Kind() != reflect.Ptr || configValue.IsNil() {
+		return fmt.Errorf("Config %v should be addressable", config)
+	}
+
+	if err := setPath(configValue.Elem(), splitPath(path), value); err != nil {
+		return fmt.Errorf("failed to set %v: %v", path, err)
+	}
+	return nil
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+func lookupPath(value reflect.Value, segments []string) (reflect.Value, error) {
+	for i, segment := range segments {
+		for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return reflect.Value{}, fmt.Errorf("%v is nil", strings.Join(segments[:i], "."))
+			}
+			value = value.Elem()
+		}
+
+		var err error
+		switch value.Kind() {
+		case reflect.Struct:
+			var ok bool
+			if value, ok = structField(value, segment, false); !ok {
+				err = errors.New("no such field")
+			}
+		case reflect.Map:
+			var key reflect.Value
+			if key, err = mapKey(value.Type(), segment); err == nil {
+				if value = value.MapIndex(key); !value.IsValid() {
+					err = errors.New("no such key")
+				}
+			}
+		case reflect.Slice, reflect.Array:
+			var index int
+			if index, err = strconv.Atoi(segment); err == nil {
+				if index < 0 || index >= value.Len() {
+					err = errors.New("index out of range")
+				} else {
+					value = value.Index(index)
+				}
+			}
+		default:
+			err = fmt.Errorf("can't get a field of %v", value.Type())
+		}
+
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%v: %v", strings.Join(segments[:i+1], "."), err)
+		}
+	}
+
+	if !value.IsValid() || !value.CanInterface() {
+		return reflect.Value{}, errors.New("invalid config")
+	}
+	return value, nil
+}
+
+func setPath(target reflect.Value, segments []string, value interface{}) error {
+	if len(segments) == 0 {
+		return assignValue(target, value)
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return setPath(target.Elem(), segments, value)
+	case reflect.Interface:
+		if target.IsNil() {
+			return fmt.Errorf("%v is nil", segments[0])
+		}
+		elem := reflect.New(target.Elem().Type()).Elem()
+		elem.Set(target.Elem())
+		if err := setPath(elem, segments, value); err != nil {
+			return err
+		}
+		target.Set(elem)
+		return nil
+	case reflect.Struct:
+		field, ok := structField(target, segments[0], true)
+		if !ok {
+			return fmt.Errorf("%v: no such field", segments[0])
+		}
+		return setPath(field, segments[1:], value)
+	case reflect.Map:
+		key, err := mapKey(target.Type(), segments[0])
+		if err != nil {
+			return err
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+
+		// map elements aren't addressable, so modify a copy and put it back
+		elem := reflect.New(target.Type().Elem()).Elem()
+		if existing := target.MapIndex(key); existing.IsValid() {
+			elem.Set(existing)
+		}
+		if err := setPath(elem, segments[1:], value); err != nil {
+			return err
+		}
+		target.SetMapIndex(key, elem)
+		return nil
+	case reflect.Slice, reflect.Array:
+		index, err := strconv.Atoi(segments[0])
+		if err != nil {
+			return err
+		}
+		if target.Kind() == reflect.Slice && index == target.Len() {
+			target.Set(reflect.Append(target, reflect.New(target.Type().Elem()).Elem()))
+		}
+		if index < 0 || index >= target.Len() {
+			return fmt.Errorf("%v: index out of range", index)
+		}
+		return setPath(target.Index(index), segments[1:], value)
+	}
+	return fmt.Errorf("can't set a field of %v", target.Type())
+}
+
+// assignValue assigns value to target, converting between numeric types and
+// parsing strings if required
+func assignValue(target reflect.Value, value interface{}) error {
+	if value == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(target.Type()):
+		target.Set(v)
+	case target.Kind() == reflect.Ptr && v.Type().AssignableTo(target.Type().Elem()):
+		ptr := reflect.New(target.Type().Elem())
+		ptr.Elem().Set(v)
+		target.Set(ptr)
+	case v.Kind() == reflect.String:
+		return setFieldFromString(target, v.String())
+	case isNumberKind(v.Kind()) && isNumberKind(target.Kind()), v.Kind() == target.Kind() && v.Type().ConvertibleTo(target.Type()):
+		target.Set(v.Convert(target.Type()))
+	default:
+		return fmt.Errorf("can't assign %T to %v", value, target.Type())
+	}
+	return nil
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
+
+func mapKey(mapType reflect.Type, segment string) (reflect.Value, error) {
+	key := reflect.New(mapType.Key()).Elem()
+	if err := setFieldFromString(key, segment); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid key %v: %v", segment, err)
+	}
+	return key, nil
+}
+
+// structField returns the field of the struct value which can be addressed by
+// key. Fields of embedded structs are promoted, allocating nil embedded
+// pointers if alloc is true.
+func structField(value reflect.Value, key string, alloc bool) (reflect.Value, bool) {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		fieldStruct := valueType.Field(i)
+		if isIgnoredField(&fieldStruct) {
+			continue
+		}
+
+		if fieldMatchesKey(&fieldStruct, key) {
+			if fieldStruct.PkgPath != "" {
+				continue
+			}
+			return value.Field(i), true
+		}
+	}
+
+	// look into embedded structs after the fields of the struct itself
+	for i := 0; i < valueType.NumField(); i++ {
+		fieldStruct := valueType.Field(i)
+		if !fieldStruct.Anonymous || hasKeyTag(&fieldStruct) || isIgnoredField(&fieldStruct) {
+			continue
+		}
+
+		embedded := value.Field(i)
+		if embedded.Kind() == reflect.Ptr {
+			if embedded.IsNil() {
+				if !alloc || !embedded.CanSet() || embedded.Type().Elem().Kind() != reflect.Struct {
+					continue
+				}
+				embedded.Set(reflect.New(embedded.Type().Elem()))
+			}
+			embedded = embedded.Elem()
+		}
+
+		if embedded.Kind() == reflect.Struct {
+			if field, ok := structField(embedded, key, alloc); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// findStructField returns the field of the struct type which can be addressed
+// by key, like structField does for values
+func findStructField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if fieldStruct.PkgPath == "" && !isIgnoredField(&fieldStruct) && fieldMatchesKey(&fieldStruct, key) {
+			return fieldStruct, true
+		}
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if !fieldStruct.Anonymous || hasKeyTag(&fieldStruct) || isIgnoredField(&fieldStruct) {
+			continue
+		}
+
+		embeddedType := fieldStruct.Type
+		if embeddedType.Kind() == reflect.Ptr {
+			embeddedType = embeddedType.Elem()
+		}
+		if embeddedType.Kind() == reflect.Struct {
+			if field, ok := findStructField(embeddedType, key); ok {
+				return field, true
+			}
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// tagKey returns the name given to the field by the struct tag, or an empty
+// string if there is none
+func tagKey(fieldStruct *reflect.StructField, tag string) string {
+	name := strings.Split(fieldStruct.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func hasKeyTag(fieldStruct *reflect.StructField) bool {
+	for _, tag := range keyTags {
+		if tagKey(fieldStruct, tag) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldMatchesKey(fieldStruct *reflect.StructField, key string) bool {
+	for _, tag := range keyTags {
+		if name := tagKey(fieldStruct, tag); name != "" && name == key {
+			return true
+		}
+	}
+	return strings.EqualFold(fieldStruct.Name, key)
+}
+
+// configKey returns the key the field is stored as in config files. It is the
+// name given by the yaml, json or toml tag, or the lower cased field name.
+func configKey(fieldStruct *reflect.StructField) string {
+	for _, tag := range keyTags {
+		if name := tagKey(fieldStruct, tag); name != "" {
+			return name
+		}
+	}
+	return strings.ToLower(fieldStruct.Name)
+}
+
+// isIgnoredField reports whether the field is never read from config files
+func isIgnoredField(fieldStruct *reflect.StructField) bool {
+	if fieldStruct.PkgPath != "" && !fieldStruct.Anonymous {
+		return true
+	}
+	for _, tag := range keyTags {
+		if fieldStruct.Tag.Get(tag) == "-" {
+			return true
+		}
+	}
+	return false
+}
+
+// isInlineField reports whether the fields of an embedded struct are stored
+// at the level of the struct embedding it
+func isInlineField(fieldStruct *reflect.StructField) bool {
+	if strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") {
+		return true
+	}
+	return fieldStruct.Anonymous && !hasKeyTag(fieldStruct)
+}
@@ -0,0 +1,148 @@
+package configService
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type PathBase struct {
+	Version int
+}
+
+type pathReplica struct {
+	Host string `yaml:"hostname"`
+	Port *int
+}
+
+type pathConfig struct {
+	PathBase
+	*PathLimits
+	DB struct {
+		Name     string `json:"db_name"`
+		Replicas []pathReplica
+	}
+	Labels  map[string]string
+	Ports   map[int]pathReplica
+	Owner   *pathReplica
+	Any     interface{}
+	Secret  string `yaml:"-"`
+	private string
+}
+
+type PathLimits struct {
+	Max int `toml:"max_items"`
+}
+
+func TestGet(t *testing.T) {
+	port := 80
+	config := &pathConfig{PathBase: PathBase{Version: 2}, Labels: map[string]string{"env": "prod"}, Secret: "s", private: "p"}
+	config.DB.Name = "app"
+	config.DB.Replicas = []pathReplica{{Host: "a", Port: &port}}
+	config.Ports = map[int]pathReplica{8080: {Host: "b"}}
+
+	tests := []struct {
+		path string
+		want interface{}
+	}{
+		{"db.db_name", "app"},
+		{"DB.Name", "app"},
+		{"db.replicas.0.hostname", "a"},
+		{"db.replicas.0.port", &port},
+		{"version", 2},
+		{"labels.env", "prod"},
+		{"ports.8080.hostname", "b"},
+		{"", config},
+	}
+	for _, test := range tests {
+		got, err := Get(config, test.path)
+		if err != nil {
+			t.Errorf("%v: %v", test.path, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %v, want %v", test.path, got, test.want)
+		}
+	}
+
+	errors := []struct {
+		path string
+		err  string
+	}{
+		{"db.missing", "db.missing: no such field"},
+		{"db.replicas.1.hostname", "db.replicas.1: index out of range"},
+		{"db.replicas.x", "db.replicas.x: strconv.Atoi"},
+		{"labels.dev", "labels.dev: no such key"},
+		{"ports.http", "ports.http: invalid key http"},
+		{"owner.hostname", "owner is nil"},
+		{"max_items", "max_items: no such field"},
+		{"db.db_name.x", "db.db_name.x: can't get a field of string"},
+		{"secret", "secret: no such field"},
+		{"private", "private: no such field"},
+	}
+	for _, test := range errors {
+		if _, err := Get(config, test.path); err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%v: got %v, want %v", test.path, err, test.err)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	config := &pathConfig{}
+	sets := []struct {
+		path  string
+		value interface{}
+	}{
+		{"db.db_name", "app"},
+		{"db.replicas.0.hostname", "a"},
+		{"db.replicas.0.port", "80"},
+		{"db.replicas.1.port", 81},
+		{"version", int64(3)},
+		{"max_items", "10"},
+		{"labels.env", "prod"},
+		{"ports.8080.hostname", "b"},
+		{"owner.hostname", "c"},
+		{"any", "x"},
+	}
+	for _, set := range sets {
+		if err := Set(config, set.path, set.value); err != nil {
+			t.Fatalf("%v: %v", set.path, err)
+		}
+	}
+
+	if config.DB.Name != "app" || len(config.DB.Replicas) != 2 || config.DB.Replicas[0].Host != "a" ||
+		*config.DB.Replicas[0].Port != 80 || *config.DB.Replicas[1].Port != 81 {
+		t.Errorf("got db %+v", config.DB)
+	}
+	if config.Version != 3 || config.PathLimits == nil || config.Max != 10 {
+		t.Errorf("got embedded structs %+v %+v", config.PathBase, config.PathLimits)
+	}
+	if config.Labels["env"] != "prod" || config.Ports[8080].Host != "b" || config.Owner == nil || config.Owner.Host != "c" || config.Any != "x" {
+		t.Errorf("got %+v", config)
+	}
+
+	if err := Set(config, "labels.env", nil); err != nil || config.Labels["env"] != "" {
+		t.Errorf("got %v and %q, want nil to set the zero value", err, config.Labels["env"])
+	}
+
+	errors := []struct {
+		path  string
+		value interface{}
+		err   string
+	}{
+		{"db.replicas.3.hostname", "x", "failed to set db.replicas.3.hostname: 3: index out of range"},
+		{"db.replicas.0.port", "eighty", "failed to set db.replicas.0.port"},
+		{"version", []string{}, "failed to set version: can't assign []string to int"},
+		{"ports.http.hostname", "x", "failed to set ports.http.hostname: invalid key http"},
+		{"db.missing", "x", "failed to set db.missing: missing: no such field"},
+		{"secret", "x", "failed to set secret: secret: no such field"},
+		{"private", "x", "failed to set private: private: no such field"},
+	}
+	for _, test := range errors {
+		if err := Set(config, test.path, test.value); err == nil || !strings.HasPrefix(err.Error(), test.err) {
+			t.Errorf("%v: got %v, want %v", test.path, err, test.err)
+		}
+	}
+
+	if err := Set(*config, "version", 1); err == nil {
+		t.Error("expected an error for a config which isn't a pointer")
+	}
+}
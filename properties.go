@@ -0,0 +1,184 @@
+package configService
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FormatProperties is the format of Java properties files. Dotted keys map
+// to nested structs and maps, e.g. database.replica.host, and items of lists
+// are addressed by their index, e.g. hosts.0 = a.
+const FormatProperties Format = "properties"
+
+func init() {
+	registerFormat(&registeredFormat{
+		name:           FormatProperties,
+		extensions:     []string{".properties"},
+		decoder:        unmarshalProperties,
+		encoder:        marshalProperties,
+		decodeDocument: decodeStringTreeDocument(parseProperties),
+		locate:         locateStringTree(parseProperties),
+		stringTree:     true,
+	})
+}
+
+func unmarshalProperties(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+	tree, err := parseProperties(data, nil)
+	if err != nil {
+		return err
+	}
+	return decodeStringTree(tree, config, errorOnUnmatchedKeys)
+}
+
+// parseProperties parses a properties file into a string tree, following
+// the rules of java.util.Properties. The line of each key is added to
+// positions unless it is nil.
+func parseProperties(data []byte, positions map[string]int) (map[string]interface{}, error) {
+	tree := map[string]interface{}{}
+	lines := strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+
+	for number := 0; number < len(lines); number++ {
+		start := number
+		line := strings.TrimLeft(lines[number], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// lines ending with an odd number of backslashes are continued
+		for hasContinuation(line) && number+1 < len(lines) {
+			number++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[number], " \t\f")
+		}
+		if hasContinuation(line) {
+			line = line[:len(line)-1]
+		}
+
+		key, value, err := splitProperty(line)
+		if err != nil {
+			return nil, &syntaxError{line: start + 1, message: err.Error()}
+		}
+		if positions != nil {
+			positions[key] = start + 1
+		}
+		if err := setTreeValue(tree, strings.Split(key, "."), value); err != nil {
+			return nil, &syntaxError{line: start + 1, message: err.Error()}
+		}
+	}
+	return tree, nil
+}
+
+func hasContinuation(line string) bool {
+	backslashes := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		backslashes++
+	}
+	return backslashes%2 == 1
+}
+
+// splitProperty splits a logical line at the first unescaped '=', ':' or
+// whitespace and unescapes key and value
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	return key, value, err
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			result.WriteByte(s[i])
+			continue
+		}
+
+		i++
+		switch s[i] {
+		case 't':
+			result.WriteByte('\t')
+		case 'n':
+			result.WriteByte('\n')
+		case 'r':
+			result.WriteByte('\r')
+		case 'f':
+			result.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid escape sequence %v", s[i-1:])
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape sequence %v", s[i-1:i+5])
+			}
+			result.WriteRune(rune(code))
+			i += 4
+		default:
+			result.WriteByte(s[i])
+		}
+	}
+	return result.String(), nil
+}
+
+// marshalProperties encodes config as properties file
+func marshalProperties(config interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	for _, entry := range flattenValue(reflect.ValueOf(config), nil) {
+		buffer.WriteString(escapeProperty(strings.Join(entry.keys, "."), true))
+		buffer.WriteString(" = ")
+		buffer.WriteString(escapeProperty(entry.value, false))
+		buffer.WriteString("\n")
+	}
+	return buffer.Bytes(), nil
+}
+
+func escapeProperty(s string, isKey bool) string {
+	var result strings.Builder
+	for i, c := range s {
+		switch {
+		case c == '\\':
+			result.WriteString(`\\`)
+		case c == '\t':
+			result.WriteString(`\t`)
+		case c == '\n':
+			result.WriteString(`\n`)
+		case c == '\r':
+			result.WriteString(`\r`)
+		case c == '\f':
+			result.WriteString(`\f`)
+		case c == ' ' && (isKey || i == 0):
+			result.WriteString(`\ `)
+		case isKey && strings.ContainsRune("=:#!", c):
+			result.WriteRune('\\')
+			result.WriteRune(c)
+		default:
+			result.WriteRune(c)
+		}
+	}
+	return result.String()
+}
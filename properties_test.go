@@ -0,0 +1,94 @@
+package configService
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want map[string]interface{}
+	}{
+		{"separators", "a=1\nb: 2\nc 3\nd   =   4\n", map[string]interface{}{"a": "1", "b": "2", "c": "3", "d": "4"}},
+		{"comments", "# comment\n! comment\n  # indented\na=1\n", map[string]interface{}{"a": "1"}},
+		{"crlf", "a=1\r\nb=2\r\n", map[string]interface{}{"a": "1", "b": "2"}},
+		{"empty value", "a=\nb\n", map[string]interface{}{"a": "", "b": ""}},
+		{"value with separators", "a=b=c: d\n", map[string]interface{}{"a": "b=c: d"}},
+		{"trailing hash", "a=1 # not a comment\n", map[string]interface{}{"a": "1 # not a comment"}},
+		{"nested", "db.host=h\ndb.port=1\nhosts.0=a\n", map[string]interface{}{"db": map[string]interface{}{"host": "h", "port": "1"}, "hosts": map[string]interface{}{"0": "a"}}},
+		{"escapes", `a=tab\tnew\nline\\\u00e4\x`, map[string]interface{}{"a": "tab\tnew\nline\\\u00e4x"}},
+		{"escaped key separators", `a\=b\:c\ d=1`, map[string]interface{}{"a=b:c d": "1"}},
+		{"continuation", "a=1, \\\n    2, \\\n    3\nb=4\n", map[string]interface{}{"a": "1, 2, 3", "b": "4"}},
+		{"escaped backslash", "a=c:\\\\\nb=2\n", map[string]interface{}{"a": `c:\`, "b": "2"}},
+		{"continuation at end", "a=1\\", map[string]interface{}{"a": "1"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseProperties([]byte(test.data), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePropertiesPositions(t *testing.T) {
+	positions := map[string]int{}
+	if _, err := parseProperties([]byte("# comment\na=1, \\\n  2\nb.c=3\n"), positions); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]int{"a": 2, "b.c": 4}; !reflect.DeepEqual(positions, want) {
+		t.Errorf("got %v, want %v", positions, want)
+	}
+}
+
+func TestParsePropertiesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"invalid unicode escape", "a=1\nb=\\u12g4\n", "line 2: invalid escape sequence \\u12g4"},
+		{"short unicode escape", "a=\\u12", "line 1: invalid escape sequence \\u12"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parseProperties([]byte(test.data), nil)
+			if err == nil || err.Error() != test.err {
+				t.Errorf("got error %v, want %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestEscapeProperty(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"a", "1"},
+		{"a b=c:d#e!f", " leading space"},
+		{"path", `c:\dir`},
+		{"lines", "1\n2\r\t\f"},
+		{"unicode", "äöü"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.key, func(t *testing.T) {
+			line := escapeProperty(test.key, true) + " = " + escapeProperty(test.value, false)
+			got, err := parseProperties([]byte(line), nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := map[string]interface{}{test.key: test.value}; !reflect.DeepEqual(got, want) {
+				t.Errorf("got %#v, want %#v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package configService
+
+import (
+	"reflect"
+	"strings"
+)
+
+// RestartRequiredField is a field tagged with `reload:"restart"` whose value
+// changed on reload. The old value is kept until the application restarts.
+type RestartRequiredField struct {
+	// Path is the dotted key path of the field, e.g. "server.listen"
+	Path string
+
+	// Old is the value which is kept, New the value of the configuration
+	// files
+	Old interface{}
+	New interface{}
+}
+
+// keepRestartFields keeps the old values of the fields tagged with
+// `reload:"restart"` in the reloaded config and returns those which changed
+func keepRestartFields(old, reloaded reflect.Value, keys []string) []RestartRequiredField {
+	for old.Kind() == reflect.Ptr {
+		if old.IsNil() || reloaded.IsNil() {
+			return nil
+		}
+		old, reloaded = old.Elem(), reloaded.Elem()
+	}
+	if old.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []RestartRequiredField
+	for i := 0; i < old.NumField(); i++ {
+		fieldStruct := old.Type().Field(i)
+		if fieldStruct.PkgPath != "" && !fieldStruct.Anonymous {
+			continue
+		}
+
+		fieldKeys := keys
+		if !isInlineField(&fieldStruct) {
+			fieldKeys = appendKey(keys, configKey(&fieldStruct))
+		}
+
+		oldField, reloadedField := old.Field(i), reloaded.Field(i)
+		if fieldStruct.Tag.Get("reload") != "restart" {
+			fields = append(fields, keepRestartFields(oldField, reloadedField, fieldKeys)...)
+			continue
+		}
+
+		if !reflect.DeepEqual(oldField.Interface(), reloadedField.Interface()) {
+			fields = append(fields, RestartRequiredField{Path: strings.Join(fieldKeys, "."), Old: oldField.Interface(), New: reloadedField.Interface()})
+			reloadedField.Set(copyValue(oldField))
+		}
+	}
+	return fields
+}
+
+// reportRestartRequired passes the changed fields to the
+// RestartRequiredCallback or logs them
+func (configService *ConfigService) reportRestartRequired(fields []RestartRequiredField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	if configService.Config.RestartRequiredCallback != nil {
+		configService.Config.RestartRequiredCallback(fields)
+		return
+	}
+	for _, field := range fields {
+		configService.logf("Configuration %v changed from %v to %v, a restart is required to apply it\n", field.Path, field.Old, field.New)
+	}
+}
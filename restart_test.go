@@ -0,0 +1,121 @@
+package configService
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type restartConfig struct {
+	Server struct {
+		Listen  string `reload:"restart"`
+		Timeout int
+	}
+	Workers       *int              `reload:"restart"`
+	Plugins       map[string]string `reload:"restart"`
+	restartLimits `yaml:",inline"`
+}
+
+type restartLimits struct {
+	MaxConnections int `yaml:"max_connections" reload:"restart"`
+}
+
+func TestKeepRestartFields(t *testing.T) {
+	workers, reloadedWorkers := 1, 2
+	old := restartConfig{Workers: &workers, Plugins: map[string]string{"a": "1"}}
+	old.Server.Listen, old.Server.Timeout, old.MaxConnections = ":80", 1, 10
+
+	reloaded := restartConfig{Workers: &reloadedWorkers, Plugins: map[string]string{"a": "1"}}
+	reloaded.Server.Listen, reloaded.Server.Timeout, reloaded.MaxConnections = ":81", 2, 20
+
+	fields := keepRestartFields(reflect.ValueOf(&old), reflect.ValueOf(&reloaded), nil)
+	want := []RestartRequiredField{
+		{Path: "server.listen", Old: ":80", New: ":81"},
+		{Path: "workers", Old: &workers, New: &reloadedWorkers},
+		{Path: "max_connections", Old: 10, New: 20},
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("got %+v, want %+v", fields, want)
+	}
+
+	if reloaded.Server.Listen != ":80" || *reloaded.Workers != 1 || reloaded.MaxConnections != 10 {
+		t.Errorf("got %+v, want the old values of restart fields", reloaded)
+	}
+	if reloaded.Server.Timeout != 2 {
+		t.Errorf("got timeout %v, want other fields to be reloaded", reloaded.Server.Timeout)
+	}
+
+	// the kept values aren't shared with the old config
+	*old.Workers = 3
+	if *reloaded.Workers != 1 {
+		t.Errorf("got workers %v, want a copy of the old value", *reloaded.Workers)
+	}
+}
+
+func TestReloadKeepsRestartFields(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "server:\n  listen: \":80\"\n  timeout: 1\n"})
+	file := filepath.Join(dir, "config.yml")
+
+	restarts := make(chan []RestartRequiredField, 1)
+	reloaded := make(chan restartConfig, 1)
+	service := New(&Config{
+		Silent:             true,
+		AutoReload:         true,
+		AutoReloadInterval: 10 * time.Millisecond,
+		RestartRequiredCallback: func(fields []RestartRequiredField) {
+			restarts <- fields
+		},
+		AutoReloadCallback: func(config interface{}) {
+			reloaded <- *config.(*restartConfig)
+		},
+	})
+	config := &restartConfig{}
+	if err := service.Load(config, file); err != nil {
+		t.Fatal(err)
+	}
+
+	rewriteTestFile(t, file, "server:\n  listen: \":81\"\n  timeout: 2\n")
+	select {
+	case fields := <-restarts:
+		want := []RestartRequiredField{{Path: "server.listen", Old: ":80", New: ":81"}}
+		if !reflect.DeepEqual(fields, want) {
+			t.Errorf("got %+v, want %+v", fields, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("RestartRequiredCallback wasn't called")
+	}
+	if got := <-reloaded; got.Server.Listen != ":80" || got.Server.Timeout != 2 {
+		t.Errorf("got %+v, want the old listen address and the new timeout", got.Server)
+	}
+}
+
+func TestReloadLogsRestartFields(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "max_connections: 1\n"})
+	file := filepath.Join(dir, "config.yml")
+
+	logger := &testLogger{}
+	reloaded := make(chan struct{}, 1)
+	service := New(&Config{
+		Logger:             logger,
+		AutoReload:         true,
+		AutoReloadInterval: 10 * time.Millisecond,
+		AutoReloadCallback: func(interface{}) {
+			reloaded <- struct{}{}
+		},
+	})
+	if err := service.Load(&restartConfig{}, file); err != nil {
+		t.Fatal(err)
+	}
+
+	rewriteTestFile(t, file, "max_connections: 2\n")
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config wasn't reloaded")
+	}
+	if !strings.Contains(logger.String(), "Configuration max_connections changed from 1 to 2, a restart is required to apply it") {
+		t.Errorf("got log %q", logger)
+	}
+}
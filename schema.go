@@ -0,0 +1,279 @@
+package configService
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const jsonSchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// GenerateJSONSchema generates a JSON Schema (draft 2020-12) describing the
+// config files of the given config struct. Besides the field types it maps
+// the following struct tags:
+//
+//	required:"true"       the key is listed as required unless it has a default
+//	default:"value"       the default value
+//	description:"text"    a description of the key
+//	enum:"a,b,c"          the allowed values
+//	min:"1" and max:"10"  the bounds of numbers, or the length of strings,
+//	                      slices and maps
+//	pattern:"^[a-z]+$"    a regular expression strings have to match
+func GenerateJSONSchema(config interface{}) ([]byte, error) {
+	schema, err := generateSchema(config, true)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func generateSchema(config interface{}, withRequired bool) (map[string]interface{}, error) {
+	configType := reflect.TypeOf(config)
+	for configType != nil && configType.Kind() == reflect.Ptr {
+		configType = configType.Elem()
+	}
+	if configType == nil || configType.Kind() != reflect.Struct {
+		return nil, errors.New("invalid config, should be struct")
+	}
+
+	generator := &schemaGenerator{withRequired: withRequired, visiting: map[reflect.Type]bool{}}
+	schema := generator.typeSchema(configType)
+	if generator.err != nil {
+		return nil, generator.err
+	}
+	schema["$schema"] = jsonSchemaDraft
+	if configType.Name() != "" {
+		schema["title"] = configType.Name()
+	}
+	return schema, nil
+}
+
+type schemaGenerator struct {
+	withRequired bool
+	visiting     map[reflect.Type]bool
+
+	// err is the first invalid struct tag found
+	err error
+}
+
+func (generator *schemaGenerator) typeSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case durationType:
+		return map[string]interface{}{"type": []string{"string", "integer"}}
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"}
+		}
+		schema := map[string]interface{}{"type": "array", "items": generator.typeSchema(t.Elem())}
+		if t.Kind() == reflect.Array {
+			schema["minItems"] = t.Len()
+			schema["maxItems"] = t.Len()
+		}
+		return schema
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": generator.typeSchema(t.Elem())}
+	case reflect.Struct:
+		if generator.visiting[t] {
+			// recursive types are only described down to the first repetition
+			return map[string]interface{}{"type": "object"}
+		}
+		generator.visiting[t] = true
+		defer delete(generator.visiting, t)
+
+		schema := map[string]interface{}{"type": "object"}
+		properties := map[string]interface{}{}
+		var required []string
+		generator.structProperties(t, properties, &required)
+		schema["properties"] = properties
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+
+	// interfaces and everything else accept any value
+	return map[string]interface{}{}
+}
+
+func (generator *schemaGenerator) structProperties(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if isIgnoredField(&fieldStruct) {
+			continue
+		}
+
+		fieldType := fieldStruct.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+
+		if isInlineField(&fieldStruct) && fieldType.Kind() == reflect.Struct {
+			generator.structProperties(fieldType, properties, required)
+			continue
+		}
+		if fieldStruct.PkgPath != "" {
+			continue
+		}
+
+		switch fieldType.Kind() {
+		case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+			continue
+		}
+
+		key := configKey(&fieldStruct)
+		properties[key] = generator.fieldSchema(t, &fieldStruct, fieldType)
+
+		if generator.withRequired && fieldStruct.Tag.Get("required") == "true" && fieldStruct.Tag.Get("default") == "" {
+			*required = append(*required, key)
+		}
+	}
+}
+
+func (generator *schemaGenerator) fieldSchema(t reflect.Type, fieldStruct *reflect.StructField, fieldType reflect.Type) map[string]interface{} {
+	schema := generator.typeSchema(fieldType)
+
+	if description := fieldStruct.Tag.Get("description"); description != "" {
+		schema["description"] = description
+	}
+
+	if value := fieldStruct.Tag.Get("default"); value != "" {
+		schema["default"] = tagValue(value, fieldType)
+	}
+
+	if values := fieldStruct.Tag.Get("enum"); values != "" {
+		var enum []interface{}
+		for _, value := range strings.Split(values, ",") {
+			enum = append(enum, tagValue(strings.TrimSpace(value), fieldType))
+		}
+		schema["enum"] = enum
+	}
+
+	if pattern := fieldStruct.Tag.Get("pattern"); pattern != "" {
+		schema["pattern"] = pattern
+	}
+
+	for tag, keywords := range map[string][4]string{
+		"min": {"minimum", "minLength", "minItems", "minProperties"},
+		"max": {"maximum", "maxLength", "maxItems", "maxProperties"},
+	} {
+		value := fieldStruct.Tag.Get(tag)
+		if value == "" {
+			continue
+		}
+
+		number, err := strconv.ParseFloat(value, 64)
+		if err == nil && !json.Valid([]byte(value)) {
+			err = errors.New("not a JSON number")
+		} else if err == nil && !isNumberKind(fieldType.Kind()) && (number < 0 || number != math.Trunc(number)) {
+			err = errors.New("lengths must be non-negative integers")
+		}
+		if err != nil {
+			if generator.err == nil {
+				name := fieldStruct.Name
+				if t.Name() != "" {
+					name = t.Name() + "." + name
+				}
+				generator.err = fmt.Errorf("invalid %v tag %q of field %v: %v", tag, value, name, err)
+			}
+			continue
+		}
+
+		switch {
+		case isNumberKind(fieldType.Kind()):
+			schema[keywords[0]] = json.Number(value)
+		case fieldType.Kind() == reflect.String:
+			schema[keywords[1]] = json.Number(value)
+		case fieldType.Kind() == reflect.Slice, fieldType.Kind() == reflect.Array:
+			schema[keywords[2]] = json.Number(value)
+		case fieldType.Kind() == reflect.Map, fieldType.Kind() == reflect.Struct:
+			schema[keywords[3]] = json.Number(value)
+		}
+	}
+
+	return schema
+}
+
+// tagValue converts a value given in a struct tag into the value written to
+// the schema
+func tagValue(value string, t reflect.Type) interface{} {
+	if t.Kind() == reflect.String || t == durationType || t == timeType {
+		return value
+	}
+
+	var result interface{}
+	if err := yaml.Unmarshal([]byte(value), &result); err != nil {
+		return value
+	}
+	return normalizeValue(result)
+}
+
+// normalizeValue converts the maps decoded by yaml into maps with string keys,
+// so the value can be encoded as JSON
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			result[toString(key)] = normalizeValue(elem)
+		}
+		return result
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = normalizeValue(elem)
+		}
+		return v
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = normalizeValue(elem)
+		}
+		return v
+	case []map[string]interface{}:
+		result := make([]interface{}, len(v))
+		for i, elem := range v {
+			result[i] = normalizeValue(elem)
+		}
+		return result
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+func toString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return strings.Trim(string(data), "\"")
+}
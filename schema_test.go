@@ -0,0 +1,137 @@
+package configService
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	type schemaServer struct {
+		Host string `required:"true" description:"the host name"`
+		Port int    `default:"80" min:"1" max:"65535" required:"true"`
+	}
+	type schemaConfig struct {
+		Name    string            `yaml:"app_name" pattern:"^[a-z]+$" min:"2" max:"10"`
+		Mode    string            `enum:"dev, prod"`
+		Level   int               `enum:"1,2,3"`
+		Ratio   float64           `min:"-0.5" max:"1.5"`
+		Count   uint              `default:"3"`
+		Tags    []string          `min:"1"`
+		Labels  map[string]string `max:"5"`
+		Servers [2]schemaServer
+		Timeout time.Duration `default:"1s"`
+		Started time.Time
+		Any     interface{}
+		Key     []byte
+		Secret  string `json:"-"`
+		Handler func()
+		private string
+	}
+
+	data, err := GenerateJSONSchema(&schemaConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	server := properties["servers"].(map[string]interface{})["items"].(map[string]interface{})
+	tests := []struct {
+		name string
+		got  interface{}
+		want string
+	}{
+		{"root", []interface{}{schema["$schema"], schema["title"]}, `["https://json-schema.org/draft/2020-12/schema","schemaConfig"]`},
+		{"pattern and string length", properties["app_name"], `{"maxLength":10,"minLength":2,"pattern":"^[a-z]+$","type":"string"}`},
+		{"string enum", properties["mode"], `{"enum":["dev","prod"],"type":"string"}`},
+		{"number enum", properties["level"], `{"enum":[1,2,3],"type":"integer"}`},
+		{"number bounds", properties["ratio"], `{"maximum":1.5,"minimum":-0.5,"type":"number"}`},
+		{"unsigned default", properties["count"], `{"default":3,"minimum":0,"type":"integer"}`},
+		{"items", properties["tags"], `{"items":{"type":"string"},"minItems":1,"type":"array"}`},
+		{"properties", properties["labels"], `{"additionalProperties":{"type":"string"},"maxProperties":5,"type":"object"}`},
+		{"array length", []interface{}{properties["servers"].(map[string]interface{})["minItems"], properties["servers"].(map[string]interface{})["maxItems"]}, `[2,2]`},
+		{"description", server["properties"].(map[string]interface{})["host"], `{"description":"the host name","type":"string"}`},
+		{"required without default", server["required"], `["host"]`},
+		{"duration", properties["timeout"], `{"default":"1s","type":["string","integer"]}`},
+		{"time", properties["started"], `{"format":"date-time","type":"string"}`},
+		{"interface", properties["any"], `{}`},
+		{"bytes", properties["key"], `{"type":"string"}`},
+		{"ignored fields", []interface{}{properties["secret"], properties["handler"], properties["private"]}, `[null,null,null]`},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(test.got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != test.want {
+			t.Errorf("%v: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGenerateJSONSchemaInvalidBounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		config interface{}
+		err    string
+	}{
+		{"not a number", &struct {
+			Port int `min:"one"`
+		}{}, `invalid min tag "one" of field Port`},
+		{"not a JSON number", &struct {
+			Port int `max:"+5"`
+		}{}, `invalid max tag "+5" of field Port: not a JSON number`},
+		{"negative length", &struct {
+			Name string `min:"-1"`
+		}{}, `invalid min tag "-1" of field Name: lengths must be non-negative integers`},
+		{"fractional length", &struct {
+			Tags []string `max:"1.5"`
+		}{}, `invalid max tag "1.5" of field Tags: lengths must be non-negative integers`},
+		{"nested field", &struct {
+			DB schemaBounds
+		}{}, `invalid min tag "low" of field schemaBounds.Port`},
+	}
+
+	for _, test := range tests {
+		if _, err := GenerateJSONSchema(test.config); err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%v: got %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+type schemaBounds struct {
+	Port int `min:"low"`
+}
+
+func TestValidateGeneratedSchema(t *testing.T) {
+	type appConfig struct {
+		Name string `min:"2"`
+		Port int    `min:"1" max:"65535"`
+		Mode string `enum:"dev,prod"`
+	}
+
+	dir := writeTestFiles(t, map[string]string{"config.yml": "name: a\nport: 70000\nmode: test\n"})
+	err := New(&Config{ValidateSchema: true}).Load(&appConfig{}, filepath.Join(dir, "config.yml"))
+	if _, ok := err.(*SchemaError); !ok {
+		t.Fatalf("got %v, want a SchemaError", err)
+	}
+	for _, message := range []string{"config.yml:1:1: /name: must be at least 2 characters long", "config.yml:2:1: /port: must be <= 65535", "config.yml:3:1: /mode: must be one of"} {
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("got %v, want %q", err, message)
+		}
+	}
+
+	// invalid tags are reported by Load too
+	err = New(&Config{ValidateSchema: true}).Load(&struct {
+		Port int `min:"one"`
+	}{}, filepath.Join(dir, "config.yml"))
+	if err == nil || !strings.Contains(err.Error(), `invalid min tag "one"`) {
+		t.Errorf("got %v, want an invalid tag error", err)
+	}
+}
@@ -0,0 +1,363 @@
+package configService
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// String trees are the generic documents of formats which only know string
+// values, like INI and properties files. Their nodes are maps whose keys are
+// the segments of dotted keys, and numeric keys address items of slices.
+
+// setTreeValue sets the value at keys inside tree, creating missing nodes
+func setTreeValue(tree map[string]interface{}, keys []string, value string) error {
+	node := tree
+	for i, key := range keys[:len(keys)-1] {
+		switch child := node[key].(type) {
+		case map[string]interface{}:
+			node = child
+		case nil:
+			next := map[string]interface{}{}
+			node[key] = next
+			node = next
+		default:
+			return fmt.Errorf("key %v is a value and can't contain %v", strings.Join(keys[:i+1], "."), strings.Join(keys, "."))
+		}
+	}
+
+	key := keys[len(keys)-1]
+	if _, ok := node[key].(map[string]interface{}); ok {
+		return fmt.Errorf("key %v contains other keys and can't have a value", strings.Join(keys, "."))
+	}
+	node[key] = value
+	return nil
+}
+
+// decodeStringTreeDocument decodes data of a string tree format into a
+// generic document of maps and strings, see shapeStringTree
+func decodeStringTreeDocument(parse func([]byte, map[string]int) (map[string]interface{}, error)) func([]byte) (interface{}, error) {
+	return func(data []byte) (interface{}, error) {
+		return parse(data, nil)
+	}
+}
+
+// locateStringTree returns a function finding keys in data of a string tree
+// format, see locateKey. Nodes are found at their first key.
+func locateStringTree(parse func([]byte, map[string]int) (map[string]interface{}, error)) func([]byte, []string) (int, int, bool) {
+	return func(data []byte, path []string) (int, int, bool) {
+		positions := map[string]int{}
+		if _, err := parse(data, positions); err != nil {
+			return 0, 0, false
+		}
+
+		for length := len(path); length > 0; length-- {
+			prefix := strings.Join(path[:length], ".")
+			line := positions[prefix]
+			for key, keyLine := range positions {
+				if strings.HasPrefix(key, prefix+".") && (line == 0 || keyLine < line) {
+					line = keyLine
+				}
+			}
+			if line > 0 {
+				return line, 1, length == len(path)
+			}
+		}
+		return 0, 0, false
+	}
+}
+
+// shapeStringTree converts the strings and index keyed nodes of a string tree
+// document into the values and lists of the fields they are decoded into, so
+// the document is validated and merged like those of typed formats. Strings
+// which don't fit their field are kept for the decoder to report.
+func shapeStringTree(t reflect.Type, node interface{}) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node := node.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			for key, child := range node {
+				if fieldStruct, ok := findStructField(t, key); ok {
+					node[key] = shapeStringTree(fieldStruct.Type, child)
+				}
+			}
+		case reflect.Map:
+			for key, child := range node {
+				node[key] = shapeStringTree(t.Elem(), child)
+			}
+		case reflect.Slice, reflect.Array:
+			list := make([]interface{}, len(node))
+			for key, child := range node {
+				index, err := strconv.Atoi(key)
+				if err != nil || index < 0 || index >= len(node) {
+					return node
+				}
+				list[index] = shapeStringTree(t.Elem(), child)
+			}
+			return list
+		}
+	case string:
+		var value interface{}
+		var err error
+		switch {
+		case t == durationType:
+			return node
+		case t.Kind() == reflect.Bool:
+			value, err = strconv.ParseBool(node)
+		case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+			value, err = strconv.ParseInt(node, 10, 64)
+		case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+			value, err = strconv.ParseUint(node, 10, 64)
+		case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+			value, err = strconv.ParseFloat(node, 64)
+		default:
+			return node
+		}
+		if err == nil {
+			return value
+		}
+	}
+	return node
+}
+
+// decodeStringTree decodes a string tree into config. Keys which don't match
+// any field are an error if errorOnUnmatchedKeys is true.
+func decodeStringTree(tree map[string]interface{}, config interface{}, errorOnUnmatchedKeys bool) error {
+	value := reflect.ValueOf(config)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("config %v should be a pointer", config)
+	}
+
+	var unmatched []string
+	if err := decodeTreeValue(tree, value.Elem(), nil, &unmatched); err != nil {
+		return err
+	}
+	if len(unmatched) > 0 && errorOnUnmatchedKeys {
+		sort.Strings(unmatched)
+		return fmt.Errorf("There are keys in the config file that do not match any field in the given struct: %v", unmatched)
+	}
+	return nil
+}
+
+// decodeTreeValue decodes a node of a string tree, or of a generic document,
+// into target. The keys of unmatched fields are added to unmatched.
+func decodeTreeValue(node interface{}, target reflect.Value, keys []string, unmatched *[]string) error {
+	if node == nil {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return decodeTreeValue(node, target.Elem(), keys, unmatched)
+	}
+
+	var tree map[string]interface{}
+	switch node := node.(type) {
+	case string:
+		return setTreeLeaf(target, node, keys)
+	case map[string]interface{}:
+		tree = node
+	case []interface{}:
+		return decodeTreeList(node, target, keys, unmatched)
+	default:
+		if target.Kind() == reflect.Interface && target.NumMethod() == 0 {
+			target.Set(reflect.ValueOf(node))
+			return nil
+		}
+		return setTreeLeaf(target, fmt.Sprint(node), keys)
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		for key, child := range tree {
+			field, ok := structField(target, key, true)
+			if !ok {
+				*unmatched = append(*unmatched, strings.Join(appendKey(keys, key), "."))
+				continue
+			}
+			if err := decodeTreeValue(child, field, appendKey(keys, key), unmatched); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for key, child := range tree {
+			mapKey, err := mapKey(target.Type(), key)
+			if err != nil {
+				return err
+			}
+			elem := reflect.New(target.Type().Elem()).Elem()
+			if existing := target.MapIndex(mapKey); existing.IsValid() {
+				elem.Set(existing)
+			}
+			if err := decodeTreeValue(child, elem, appendKey(keys, key), unmatched); err != nil {
+				return err
+			}
+			target.SetMapIndex(mapKey, elem)
+		}
+	case reflect.Slice, reflect.Array:
+		length := 0
+		for key := range tree {
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return keyErrorf(keys, "%v is no list index", key)
+			}
+			if index >= length {
+				length = index + 1
+			}
+		}
+
+		items := target
+		if target.Kind() == reflect.Slice {
+			items = reflect.MakeSlice(target.Type(), length, length)
+		} else if length > target.Len() {
+			return keyErrorf(keys, "list has more than %v items", target.Len())
+		}
+		for key, child := range tree {
+			index, _ := strconv.Atoi(key)
+			if err := decodeTreeValue(child, items.Index(index), appendKey(keys, key), unmatched); err != nil {
+				return err
+			}
+		}
+		target.Set(items)
+	case reflect.Interface:
+		if target.NumMethod() > 0 {
+			return keyErrorf(keys, "%v can't hold keys", target.Type())
+		}
+		target.Set(reflect.ValueOf(tree))
+	default:
+		return keyErrorf(keys, "expected a value of type %v, got keys", target.Type())
+	}
+	return nil
+}
+
+func decodeTreeList(list []interface{}, target reflect.Value, keys []string, unmatched *[]string) error {
+	switch target.Kind() {
+	case reflect.Slice:
+		items := reflect.MakeSlice(target.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeTreeValue(item, items.Index(i), appendKey(keys, strconv.Itoa(i)), unmatched); err != nil {
+				return err
+			}
+		}
+		target.Set(items)
+	case reflect.Array:
+		if len(list) > target.Len() {
+			return keyErrorf(keys, "list has more than %v items", target.Len())
+		}
+		for i, item := range list {
+			if err := decodeTreeValue(item, target.Index(i), appendKey(keys, strconv.Itoa(i)), unmatched); err != nil {
+				return err
+			}
+		}
+	case reflect.Interface:
+		if target.NumMethod() > 0 {
+			return keyErrorf(keys, "%v can't hold a list", target.Type())
+		}
+		target.Set(reflect.ValueOf(list))
+	default:
+		return keyErrorf(keys, "expected a value of type %v, got a list", target.Type())
+	}
+	return nil
+}
+
+func setTreeLeaf(target reflect.Value, value string, keys []string) error {
+	var err error
+	switch {
+	case target.Type() == durationType:
+		var duration time.Duration
+		if duration, err = time.ParseDuration(value); err == nil {
+			target.SetInt(int64(duration))
+		}
+	case target.Kind() == reflect.Interface && target.NumMethod() == 0:
+		target.Set(reflect.ValueOf(value))
+	default:
+		err = setFieldFromString(target, value)
+	}
+	if err != nil {
+		return keyErrorf(keys, "can't use %q as %v", value, target.Type())
+	}
+	return nil
+}
+
+// treeEntry is a value of a flattened configuration
+type treeEntry struct {
+	keys  []string
+	value string
+}
+
+// flattenValue returns the values of a config or generic document with
+// their key paths, sorted by key for maps
+func flattenValue(value reflect.Value, keys []string) []treeEntry {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	switch {
+	case value.Type() == timeType:
+		return []treeEntry{{keys, value.Interface().(time.Time).Format(time.RFC3339Nano)}}
+	case value.Type() == durationType:
+		return []treeEntry{{keys, value.Interface().(time.Duration).String()}}
+	}
+
+	var entries []treeEntry
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			fieldStruct := value.Type().Field(i)
+			if isIgnoredField(&fieldStruct) {
+				continue
+			}
+			if isInlineField(&fieldStruct) {
+				entries = append(entries, flattenValue(value.Field(i), keys)...)
+				continue
+			}
+			entries = append(entries, flattenValue(value.Field(i), appendKey(keys, configKey(&fieldStruct)))...)
+		}
+	case reflect.Map:
+		mapKeys := value.MapKeys()
+		sort.Slice(mapKeys, func(i, j int) bool {
+			return fmt.Sprint(mapKeys[i].Interface()) < fmt.Sprint(mapKeys[j].Interface())
+		})
+		for _, mapKey := range mapKeys {
+			entries = append(entries, flattenValue(value.MapIndex(mapKey), appendKey(keys, fmt.Sprint(mapKey.Interface())))...)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8 {
+			return []treeEntry{{keys, string(value.Bytes())}}
+		}
+		for i := 0; i < value.Len(); i++ {
+			entries = append(entries, flattenValue(value.Index(i), appendKey(keys, strconv.Itoa(i)))...)
+		}
+	default:
+		if len(keys) > 0 {
+			entries = append(entries, treeEntry{keys, fmt.Sprint(value.Interface())})
+		}
+	}
+	return entries
+}
+
+// appendKey returns a new key path of keys followed by key
+func appendKey(keys []string, key string) []string {
+	return append(append([]string{}, keys...), key)
+}
+
+func isIndexKey(key string) bool {
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
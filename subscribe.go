@@ -0,0 +1,154 @@
+package configService
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeHandler is called with the path and the old and new values of a
+// part of the config which changed on reload. Values which don't exist
+// before or after the reload are nil.
+type ChangeHandler func(path string, old, new interface{})
+
+type subscription struct {
+	pattern []string
+	handler ChangeHandler
+}
+
+// Subscribe calls handler whenever the value at the dotted path of the
+// config, or anything beneath it, changes on reload. A "*" segment matches
+// every field, map key or list index, e.g. "features.*" calls handler for
+// each feature which changed. It returns a function which cancels the
+// subscription.
+func (configService *ConfigService) Subscribe(path string, handler ChangeHandler) func() {
+	subscription := &subscription{pattern: splitPath(path), handler: handler}
+
+	configService.subscriptionsMutex.Lock()
+	defer configService.subscriptionsMutex.Unlock()
+	configService.subscriptions = append(configService.subscriptions, subscription)
+
+	return func() {
+		configService.subscriptionsMutex.Lock()
+		defer configService.subscriptionsMutex.Unlock()
+		for i, s := range configService.subscriptions {
+			if s == subscription {
+				configService.subscriptions = append(configService.subscriptions[:i], configService.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notifySubscribers calls the handlers of the subscriptions whose paths
+// changed between old and new
+func (configService *ConfigService) notifySubscribers(old, new reflect.Value) {
+	configService.subscriptionsMutex.Lock()
+	subscriptions := append([]*subscription{}, configService.subscriptions...)
+	configService.subscriptionsMutex.Unlock()
+
+	for _, subscription := range subscriptions {
+		notifyChanges(old, new, subscription.pattern, nil, subscription.handler)
+	}
+}
+
+// notifyChanges walks the pattern through old and new and calls handler for
+// each matching path whose values differ
+func notifyChanges(old, new reflect.Value, pattern, keys []string, handler ChangeHandler) {
+	if len(pattern) == 0 {
+		oldValue, newValue := valueInterface(old), valueInterface(new)
+		if !reflect.DeepEqual(oldValue, newValue) {
+			handler(strings.Join(keys, "."), oldValue, newValue)
+		}
+		return
+	}
+
+	names := []string{pattern[0]}
+	if pattern[0] == "*" {
+		names = childNames(old, new)
+	}
+	for _, name := range names {
+		notifyChanges(childValue(old, name), childValue(new, name), pattern[1:], appendKey(keys, name), handler)
+	}
+}
+
+func valueInterface(value reflect.Value) interface{} {
+	if !value.IsValid() || !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
+
+func indirect(value reflect.Value) reflect.Value {
+	for value.IsValid() && (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// childValue returns the field, map value or list item of value named name,
+// or an invalid value if there is none
+func childValue(value reflect.Value, name string) reflect.Value {
+	value = indirect(value)
+	if !value.IsValid() {
+		return value
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		if field, ok := structField(value, name, false); ok {
+			return field
+		}
+	case reflect.Map:
+		if key, err := mapKey(value.Type(), name); err == nil {
+			return value.MapIndex(key)
+		}
+	case reflect.Slice, reflect.Array:
+		if index, err := strconv.Atoi(name); err == nil && index >= 0 && index < value.Len() {
+			return value.Index(index)
+		}
+	}
+	return reflect.Value{}
+}
+
+// childNames returns the names of the fields, map keys and list indexes of
+// old and new
+func childNames(old, new reflect.Value) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, value := range []reflect.Value{indirect(old), indirect(new)} {
+		if !value.IsValid() {
+			continue
+		}
+
+		var valueNames []string
+		switch value.Kind() {
+		case reflect.Struct:
+			for _, fieldStruct := range documentFields(value.Type(), "") {
+				valueNames = append(valueNames, configKey(&fieldStruct))
+			}
+		case reflect.Map:
+			for _, key := range value.MapKeys() {
+				valueNames = append(valueNames, fmt.Sprint(key.Interface()))
+			}
+			sort.Strings(valueNames)
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < value.Len(); i++ {
+				valueNames = append(valueNames, strconv.Itoa(i))
+			}
+		}
+
+		for _, name := range valueNames {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
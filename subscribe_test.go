@@ -0,0 +1,127 @@
+package configService
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type subscribeConfig struct {
+	DB struct {
+		Host string
+		Port int
+	}
+	Features map[string]bool
+	Servers  []string
+	Owner    *struct {
+		Name string
+	}
+}
+
+func TestNotifyChanges(t *testing.T) {
+	old := subscribeConfig{Features: map[string]bool{"a": true, "b": true}, Servers: []string{"x"}}
+	old.DB.Host, old.DB.Port = "h", 1
+	new := subscribeConfig{Features: map[string]bool{"a": true, "b": false, "c": true}, Servers: []string{"x", "y"}}
+	new.DB.Host, new.DB.Port = "h", 2
+	new.Owner = &struct{ Name string }{"o"}
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"db", []string{"db: {h 1} -> {h 2}"}},
+		{"db.host", nil},
+		{"db.port", []string{"db.port: 1 -> 2"}},
+		{"DB.Port", []string{"DB.Port: 1 -> 2"}},
+		{"db.*", []string{"db.port: 1 -> 2"}},
+		{"features.*", []string{"features.b: true -> false", "features.c: <nil> -> true"}},
+		{"servers.*", []string{"servers.1: <nil> -> y"}},
+		{"owner.name", []string{"owner.name: <nil> -> o"}},
+		{"*.port", []string{"db.port: 1 -> 2"}},
+		{"missing", nil},
+	}
+
+	for _, test := range tests {
+		var got []string
+		notifyChanges(reflect.ValueOf(old), reflect.ValueOf(new), splitPath(test.pattern), nil, func(path string, old, new interface{}) {
+			got = append(got, fmt.Sprintf("%v: %v -> %v", path, old, new))
+		})
+		sort.Strings(got)
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%v: got %q, want %q", test.pattern, got, test.want)
+		}
+	}
+
+	var paths []string
+	notifyChanges(reflect.ValueOf(old), reflect.ValueOf(new), []string{"*"}, nil, func(path string, old, new interface{}) {
+		paths = append(paths, path)
+	})
+	if want := "db features servers owner"; strings.Join(paths, " ") != want {
+		t.Errorf("got %v, want %v", paths, want)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "db:\n  host: h\n  port: 1\nfeatures:\n  a: true\n"})
+	file := filepath.Join(dir, "config.yml")
+
+	reloaded := make(chan struct{}, 1)
+	service := New(&Config{
+		Silent:             true,
+		AutoReload:         true,
+		AutoReloadInterval: 10 * time.Millisecond,
+		AutoReloadCallback: func(interface{}) {
+			reloaded <- struct{}{}
+		},
+	})
+
+	// handlers run on the reload goroutine before AutoReloadCallback
+	var portChanges, featureChanges, cancelledChanges []string
+	service.Subscribe("db.port", func(path string, old, new interface{}) {
+		portChanges = append(portChanges, fmt.Sprintf("%v: %v -> %v", path, old, new))
+	})
+	service.Subscribe("features.*", func(path string, old, new interface{}) {
+		featureChanges = append(featureChanges, fmt.Sprintf("%v: %v -> %v", path, old, new))
+	})
+	cancel := service.Subscribe("db", func(path string, old, new interface{}) {
+		cancelledChanges = append(cancelledChanges, path)
+	})
+
+	config := &subscribeConfig{}
+	if err := service.Load(config, file); err != nil {
+		t.Fatal(err)
+	}
+	if len(portChanges)+len(featureChanges)+len(cancelledChanges) != 0 {
+		t.Errorf("handlers were called by the first load")
+	}
+
+	reload := func(content string) {
+		t.Helper()
+		rewriteTestFile(t, file, content)
+		select {
+		case <-reloaded:
+		case <-time.After(5 * time.Second):
+			t.Fatal("config wasn't reloaded")
+		}
+	}
+
+	reload("db:\n  host: h\n  port: 2\nfeatures:\n  a: true\n  b: true\n")
+	if strings.Join(portChanges, ",") != "db.port: 1 -> 2" || strings.Join(featureChanges, ",") != "features.b: <nil> -> true" || len(cancelledChanges) != 1 {
+		t.Errorf("got %q, %q and %q", portChanges, featureChanges, cancelledChanges)
+	}
+
+	cancel()
+	cancel()
+	reload("db:\n  host: other\n  port: 2\n")
+	if len(portChanges) != 1 || len(cancelledChanges) != 1 {
+		t.Errorf("got %q and %q, want no calls for unchanged or cancelled paths", portChanges, cancelledChanges)
+	}
+	sort.Strings(featureChanges)
+	if strings.Join(featureChanges, ",") != "features.a: true -> <nil>,features.b: <nil> -> true,features.b: true -> <nil>" {
+		t.Errorf("got %q, want the removed features", featureChanges)
+	}
+}
@@ -0,0 +1,106 @@
+package configService
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// UnknownEnvError is returned by Load if ErrorOnUnmatchedEnv is set and
+// there are environment variables starting with the ENVPrefix which don't
+// match any field of the config struct. The Key of each UnknownKey is the
+// name of a variable, its Suggestion the closest name of a field.
+type UnknownEnvError struct {
+	Prefix string
+	Keys   []UnknownKey
+}
+
+func (e *UnknownEnvError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, key := range e.Keys {
+		keys[i] = key.String()
+	}
+	return fmt.Sprintf("There are environment variables with the prefix %v that do not match any field in the given struct:\n  %v", e.Prefix, strings.Join(keys, "\n  "))
+}
+
+// controlEnvNames are the environment variables configuring the service
+// itself
+var controlEnvNames = map[string]bool{
+	"CONFIGOR_ENV":          true,
+	"CONFIGOR_ENV_PREFIX":   true,
+	"CONFIGOR_DEBUG_MODE":   true,
+	"CONFIGOR_VERBOSE_MODE": true,
+	"CONFIGOR_SILENT_MODE":  true,
+	"CONFIGOR_HOSTNAME":     true,
+	"CONFIGOR_INSTANCE_ID":  true,
+}
+
+// checkUnknownEnv reports the variables of the environment and the dotenv
+// files starting with the prefix which weren't looked up by processTags,
+// failing if ErrorOnUnmatchedEnv is set and logging them if
+// WarnOnUnmatchedEnv is set
+func (configService *ConfigService) checkUnknownEnv(prefix string) error {
+	prefix = strings.ToUpper(prefix) + "_"
+
+	var candidates []string
+	for name := range configService.envNames {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+
+	names := map[string]bool{}
+	for _, variable := range os.Environ() {
+		names[strings.SplitN(variable, "=", 2)[0]] = true
+	}
+	for name := range configService.dotenv {
+		names[name] = true
+	}
+
+	var keys []UnknownKey
+	for name := range names {
+		if !strings.HasPrefix(strings.ToUpper(name), prefix) || configService.envNames[name] || controlEnvNames[name] {
+			continue
+		}
+		keys = append(keys, UnknownKey{Key: name, Suggestion: suggestEnvName(name, prefix, candidates)})
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+
+	if configService.Config.ErrorOnUnmatchedEnv {
+		return &UnknownEnvError{Prefix: strings.TrimSuffix(prefix, "_"), Keys: keys}
+	}
+	for _, key := range keys {
+		configService.logf("Unknown environment variable %v\n", key)
+	}
+	return nil
+}
+
+// suggestEnvName returns the candidate closest to name, comparing the parts
+// after the prefix. Candidates written in the case of name are preferred.
+func suggestEnvName(name, prefix string, candidates []string) string {
+	var sameCase []string
+	for _, candidate := range candidates {
+		if (strings.ToUpper(candidate) == candidate) == (strings.ToUpper(name) == name) {
+			sameCase = append(sameCase, candidate)
+		}
+	}
+	if len(sameCase) > 0 {
+		candidates = sameCase
+	}
+
+	suffixes := make([]string, 0, len(candidates))
+	names := map[string]string{}
+	for _, candidate := range candidates {
+		if len(candidate) >= len(prefix) && strings.EqualFold(candidate[:len(prefix)], prefix) {
+			suffix := candidate[len(prefix):]
+			suffixes = append(suffixes, suffix)
+			names[suffix] = candidate
+		}
+	}
+	return names[suggestKey(name[len(prefix):], suffixes)]
+}
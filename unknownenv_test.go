@@ -0,0 +1,79 @@
+package configService
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type unknownEnvConfig struct {
+	Name string `alias:"title"`
+	DB   struct {
+		Port int
+	}
+}
+
+// setTestEnv sets the environment variables for the duration of the test
+func setTestEnv(t *testing.T, env map[string]string) {
+	t.Helper()
+	for name, value := range env {
+		os.Setenv(name, value)
+		name := name
+		t.Cleanup(func() {
+			os.Unsetenv(name)
+		})
+	}
+}
+
+func TestUnknownEnv(t *testing.T) {
+	setTestEnv(t, map[string]string{
+		"TESTAPP_NAME":        "app",
+		"TESTAPP_TITLE":       "alias",
+		"TestApp_DB_Port":     "1",
+		"TESTAPP_NMAE":        "typo",
+		"TestApp_DB_Prot":     "2",
+		"TESTAPP_DB_HOST":     "h",
+		"CONFIGOR_ENV":        "test",
+		"TESTAPPLICATION_FOO": "other prefix",
+	})
+	dir := writeTestFiles(t, map[string]string{".env": "TESTAPP_FROM_DOTENV=1\n"})
+
+	var config unknownEnvConfig
+	err := New(&Config{ENVPrefix: "TestApp", ErrorOnUnmatchedEnv: true, DotenvFiles: []string{filepath.Join(dir, ".env")}}).Load(&config)
+	unknownErr, ok := err.(*UnknownEnvError)
+	if !ok {
+		t.Fatalf("got %v, want an UnknownEnvError", err)
+	}
+	want := []UnknownKey{
+		{Key: "TESTAPP_DB_HOST", Suggestion: "TESTAPP_DB_PORT"},
+		{Key: "TESTAPP_FROM_DOTENV"},
+		{Key: "TESTAPP_NMAE", Suggestion: "TESTAPP_NAME"},
+		{Key: "TestApp_DB_Prot", Suggestion: "TestApp_DB_Port"},
+	}
+	if unknownErr.Prefix != "TESTAPP" || !reflect.DeepEqual(unknownErr.Keys, want) {
+		t.Errorf("got %v with %+v, want %+v", unknownErr.Prefix, unknownErr.Keys, want)
+	}
+	if !strings.Contains(err.Error(), "TESTAPP_NMAE, did you mean TESTAPP_NAME?") {
+		t.Errorf("got %q", err)
+	}
+
+	// the variables are only logged when warning
+	logger := &testLogger{}
+	config = unknownEnvConfig{}
+	if err := New(&Config{ENVPrefix: "TestApp", WarnOnUnmatchedEnv: true, Logger: logger}).Load(&config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" || config.DB.Port != 1 {
+		t.Errorf("got %+v", config)
+	}
+	if !strings.Contains(logger.String(), "Unknown environment variable TESTAPP_NMAE, did you mean TESTAPP_NAME?") || strings.Contains(logger.String(), "CONFIGOR_ENV") {
+		t.Errorf("got log %q", logger)
+	}
+
+	// unmatched variables are ignored by default
+	if err := New(&Config{ENVPrefix: "TestApp"}).Load(&unknownEnvConfig{}); err != nil {
+		t.Error(err)
+	}
+}
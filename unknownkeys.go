@@ -0,0 +1,395 @@
+package configService
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// UnknownKey is a key of a configuration file which doesn't match any field
+// of the config struct
+type UnknownKey struct {
+	// Key is the dotted key path, e.g. "database.max_conn"
+	Key string
+
+	// File is the configuration file, it is empty for data passed to
+	// LoadBytes and LoadReader
+	File string
+
+	// Line and Column locate the key inside the file, starting at 1. They
+	// are 0 if the position is unknown.
+	Line   int
+	Column int
+
+	// Suggestion is the key path of the field whose name is closest to the
+	// unknown key, e.g. "database.max_conns", or empty if no name is similar
+	Suggestion string
+}
+
+func (key UnknownKey) String() string {
+	result := key.Key
+	if key.File != "" {
+		result = fmt.Sprintf("%v: %v", key.File, result)
+		if key.Line > 0 {
+			result = fmt.Sprintf("%v:%v:%v: %v", key.File, key.Line, key.Column, key.Key)
+		}
+	}
+	if key.Suggestion != "" {
+		result = fmt.Sprintf("%v, did you mean %v?", result, key.Suggestion)
+	}
+	return result
+}
+
+// UnknownKeysError is returned by Load if ErrorOnUnmatchedKeys is set and a
+// configuration file contains keys which don't match any field of the config
+// struct
+type UnknownKeysError struct {
+	Keys []UnknownKey
+}
+
+func (e *UnknownKeysError) Error() string {
+	keys := make([]string, len(e.Keys))
+	for i, key := range e.Keys {
+		keys[i] = key.String()
+	}
+	return fmt.Sprintf("There are keys in the config file that do not match any field in the given struct:\n  %v", strings.Join(keys, "\n  "))
+}
+
+// checkUnknownKeys reports the keys of the document which don't match any
+// field of the config, failing if ErrorOnUnmatchedKeys is set and logging
+// them if WarnOnUnmatchedKeys is set
+func (configService *ConfigService) checkUnknownKeys(config interface{}, file string, format Format, document interface{}, locate func([]string) (int, int, bool)) error {
+	finder := &unknownKeyFinder{format: format}
+	versionKey := configService.GetVersionKey()
+	if documentMap, ok := document.(map[string]interface{}); ok && documentMap[versionKey] != nil && configService.hasMigrations() {
+		// the version may be read by migrations only
+		withoutVersion := map[string]interface{}{}
+		for key, value := range documentMap {
+			if key != versionKey {
+				withoutVersion[key] = value
+			}
+		}
+		document = withoutVersion
+	}
+
+	finder.walk(reflect.TypeOf(config), document, nil)
+	for i := range finder.keys {
+		key := &finder.keys[i]
+		key.Line, key.Column, _ = locate(strings.Split(key.Key, "."))
+	}
+	return configService.reportUnknownKeys(file, finder.keys)
+}
+
+// reportUnknownKeys returns an UnknownKeysError if ErrorOnUnmatchedKeys is
+// set, otherwise it logs the keys
+func (configService *ConfigService) reportUnknownKeys(file string, keys []UnknownKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	for i := range keys {
+		keys[i].File = file
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keys[i].Line < keys[j].Line
+	})
+
+	if configService.GetErrorOnUnmatchedKeys() {
+		return &UnknownKeysError{Keys: keys}
+	}
+	for _, key := range keys {
+		configService.logf("Unknown configuration key %v\n", key)
+	}
+	return nil
+}
+
+// unknownKeyFinder walks a document alongside the config type and collects
+// the keys which don't match any field
+type unknownKeyFinder struct {
+	format Format
+	keys   []UnknownKey
+}
+
+var (
+	yamlUnmarshalerType = reflect.TypeOf((*yaml.Unmarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodesItself reports whether values of the type decode themselves, in
+// which case they accept whatever they like
+func decodesItself(t reflect.Type) bool {
+	pointer := reflect.PtrTo(t)
+	return pointer.Implements(yamlUnmarshalerType) || pointer.Implements(jsonUnmarshalerType) || pointer.Implements(textUnmarshalerType)
+}
+
+func (finder *unknownKeyFinder) walk(t reflect.Type, node interface{}, keys []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if decodesItself(t) {
+		return
+	}
+
+	switch node := node.(type) {
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+		for i, item := range node {
+			finder.walk(t.Elem(), item, appendKey(keys, strconv.Itoa(i)))
+		}
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for _, key := range sortedKeys(node) {
+				finder.walk(t.Elem(), node[key], appendKey(keys, key))
+			}
+		case reflect.Struct:
+			finder.walkStruct(t, node, keys)
+		case reflect.Slice, reflect.Array:
+			// labelled HCL blocks of a list
+			if finder.format == FormatHCL {
+				finder.walk(t.Elem(), node, keys)
+			}
+		}
+	}
+}
+
+func (finder *unknownKeyFinder) walkStruct(t reflect.Type, node map[string]interface{}, keys []string) {
+	if labels := hclLabelCount(t); finder.format == FormatHCL && labels > 0 {
+		finder.walkLabels(t, node, keys, labels)
+		return
+	}
+	finder.walkFields(t, node, keys)
+}
+
+func (finder *unknownKeyFinder) walkFields(t reflect.Type, node map[string]interface{}, keys []string) {
+	for _, key := range sortedKeys(node) {
+		fieldStruct, ok := decoderField(t, key, finder.format)
+		if !ok {
+			unknown := UnknownKey{Key: strings.Join(appendKey(keys, key), ".")}
+			if suggestion := suggestKey(key, fieldKeys(t, finder.format)); suggestion != "" {
+				unknown.Suggestion = strings.Join(appendKey(keys, suggestion), ".")
+			}
+			finder.keys = append(finder.keys, unknown)
+			continue
+		}
+		finder.walk(fieldStruct.Type, node[key], appendKey(keys, key))
+	}
+}
+
+// walkLabels skips the labels of HCL blocks decoded into structs with label
+// fields
+func (finder *unknownKeyFinder) walkLabels(t reflect.Type, node map[string]interface{}, keys []string, labels int) {
+	for _, key := range sortedKeys(node) {
+		body, ok := node[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if labels > 1 {
+			finder.walkLabels(t, body, appendKey(keys, key), labels-1)
+			continue
+		}
+		finder.walkFields(t, body, appendKey(keys, key))
+	}
+}
+
+func hclLabelCount(t reflect.Type) int {
+	count := 0
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if isHCLLabelField(&fieldStruct) {
+			count++
+		}
+	}
+	return count
+}
+
+// fieldKeys returns the keys the fields of a struct are stored as in
+// documents of the format, including those of inline structs
+func fieldKeys(t reflect.Type, format Format) []string {
+	var keys []string
+	for _, fieldStruct := range documentFields(t, format) {
+		keys = append(keys, documentKey(&fieldStruct, format))
+	}
+	return keys
+}
+
+// decoderField returns the field of the struct type which the decoder of the
+// format decodes key into. yaml.v2 matches the yaml tag or the lower cased
+// field name, encoding/json and toml the json or toml tag or, case
+// insensitive, the field name. The other formats decode like Get.
+func decoderField(t reflect.Type, key string, format Format) (reflect.StructField, bool) {
+	switch format {
+	case FormatYAML:
+		return yamlField(t, key)
+	case FormatJSON, FormatJSONC:
+		return promotedField(t, key, "json")
+	case FormatTOML:
+		return promotedField(t, key, "toml")
+	}
+
+	fieldStruct, ok := findStructField(t, key)
+	if !ok || isIgnoredField(&fieldStruct) {
+		return reflect.StructField{}, false
+	}
+	return fieldStruct, true
+}
+
+func yamlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var inlineMap reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if fieldStruct.PkgPath != "" && !fieldStruct.Anonymous {
+			continue
+		}
+
+		options := strings.Split(fieldStruct.Tag.Get("yaml"), ",")
+		if options[0] == "-" {
+			continue
+		}
+		if strings.Contains(fieldStruct.Tag.Get("yaml"), ",inline") {
+			switch fieldStruct.Type.Kind() {
+			case reflect.Struct:
+				if field, ok := yamlField(fieldStruct.Type, key); ok {
+					return field, true
+				}
+			case reflect.Map:
+				inlineMap = fieldStruct.Type
+			}
+			continue
+		}
+
+		if yamlKey(&fieldStruct) == key {
+			return fieldStruct, true
+		}
+	}
+
+	// inline maps take all keys which don't match a field
+	if inlineMap != nil {
+		return reflect.StructField{Name: key, Type: inlineMap.Elem()}, true
+	}
+	return reflect.StructField{}, false
+}
+
+// yamlKey returns the key yaml.v2 decodes into the field
+func yamlKey(fieldStruct *reflect.StructField) string {
+	if name := tagKey(fieldStruct, "yaml"); name != "" {
+		return name
+	}
+	return strings.ToLower(fieldStruct.Name)
+}
+
+// promotedField returns the field matching key like encoding/json does,
+// promoting the fields of untagged embedded structs
+func promotedField(t reflect.Type, key, tag string) (reflect.StructField, bool) {
+	var caseInsensitive *reflect.StructField
+	for _, fieldStruct := range promotedFields(t, tag) {
+		name := tagKey(&fieldStruct, tag)
+		if name == "" {
+			name = fieldStruct.Name
+		}
+		if name == key {
+			return fieldStruct, true
+		}
+		if caseInsensitive == nil && strings.EqualFold(name, key) {
+			field := fieldStruct
+			caseInsensitive = &field
+		}
+	}
+	if caseInsensitive != nil {
+		return *caseInsensitive, true
+	}
+	return reflect.StructField{}, false
+}
+
+func promotedFields(t reflect.Type, tag string) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if fieldStruct.Tag.Get(tag) == "-" {
+			continue
+		}
+
+		fieldType := fieldStruct.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldStruct.Anonymous && tagKey(&fieldStruct, tag) == "" && fieldType.Kind() == reflect.Struct {
+			fields = append(fields, promotedFields(fieldType, tag)...)
+			continue
+		}
+		if fieldStruct.PkgPath == "" {
+			fields = append(fields, fieldStruct)
+		}
+	}
+	return fields
+}
+
+// suggestKey returns the candidate closest to key, or an empty string if no
+// candidate is similar enough
+func suggestKey(key string, candidates []string) string {
+	best, bestDistance := "", len(key)/3+1
+	if bestDistance < 2 {
+		bestDistance = 2
+	}
+	for _, candidate := range candidates {
+		if distance := levenshtein(strings.ToLower(key), strings.ToLower(candidate)); distance <= bestDistance && (best == "" || distance < bestDistance) {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance of two strings
+func levenshtein(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current := make([]int, len(target)+1)
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous = current
+	}
+	return previous[len(target)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func sortedKeys(node map[string]interface{}) []string {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+package configService
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type unknownKeysConfig struct {
+	Name     string
+	MaxConns int `yaml:"max_conns" json:"max_conns" toml:"max_conns"`
+	Timeout  time.Duration
+	Labels   map[string]string
+	Servers  []struct {
+		Host string
+	}
+	Database struct {
+		Host string
+		Port int
+	}
+}
+
+func TestUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		data string
+		want []string
+	}{
+		{"yaml", "config.yml", "name: app\nmax_con: 1\ndatabase:\n  hots: h\n  user: u\n", []string{
+			"config.yml:2:1: max_con, did you mean max_conns?",
+			"config.yml:4:3: database.hots, did you mean database.host?",
+			"config.yml:5:3: database.user",
+		}},
+		{"yaml list items", "config.yml", "servers:\n  - host: a\n  - hots: b\n", []string{
+			"config.yml:3:5: servers.1.hots, did you mean servers.1.host?",
+		}},
+		{"yaml maps accept any key", "config.yml", "labels:\n  anything: x\ntimeout: 1s\n", nil},
+		// suggestions are keys the decoder of the format matches
+		{"json", "config.json", "{\n  \"name\": \"app\",\n  \"database\": {\"prot\": 1}\n}", []string{
+			"config.json:3:16: database.prot, did you mean database.Port?",
+		}},
+		{"json matches field names case insensitive", "config.json", "{\"NAME\": \"app\", \"Max_Conns\": 1}", nil},
+		{"toml", "config.toml", "nmae = \"app\"\n\n[database]\nhost = \"h\"\nports = 1\n", []string{
+			"config.toml:1:1: nmae, did you mean Name?",
+			"config.toml:5:1: database.ports, did you mean database.Port?",
+		}},
+		{"hcl", "config.hcl", "name = \"app\"\ndatabase {\n  hots = \"h\"\n}\n", []string{
+			"config.hcl:3:10: database.hots, did you mean database.host?",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := writeTestFiles(t, map[string]string{test.file: test.data})
+			err := New(&Config{ErrorOnUnmatchedKeys: true}).Load(&unknownKeysConfig{}, filepath.Join(dir, test.file))
+			if test.want == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			unknownKeysError, ok := err.(*UnknownKeysError)
+			if !ok {
+				t.Fatalf("got %v, want an UnknownKeysError", err)
+			}
+			var got []string
+			for _, key := range unknownKeysError.Keys {
+				got = append(got, strings.TrimPrefix(key.String(), dir+string(filepath.Separator)))
+			}
+			if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+				t.Errorf("got\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestUnknownKeysWarning(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.yml": "name: app\nnmae: x\n"})
+
+	logger := &testLogger{}
+	var config unknownKeysConfig
+	if err := New(&Config{WarnOnUnmatchedKeys: true, Logger: logger}).Load(&config, filepath.Join(dir, "config.yml")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" {
+		t.Errorf("got %+v, want the known keys to be loaded", config)
+	}
+	want := "Unknown configuration key " + filepath.Join(dir, "config.yml") + ":2:1: nmae, did you mean name?"
+	if !strings.Contains(logger.String(), want) {
+		t.Errorf("got log %q, want %q", logger, want)
+	}
+}
+
+func TestUnknownKeyString(t *testing.T) {
+	tests := []struct {
+		key  UnknownKey
+		want string
+	}{
+		{UnknownKey{Key: "a.b"}, "a.b"},
+		{UnknownKey{Key: "a.b", Suggestion: "a.c"}, "a.b, did you mean a.c?"},
+		{UnknownKey{Key: "a", File: "config.yml"}, "config.yml: a"},
+		{UnknownKey{Key: "a", File: "config.yml", Line: 2, Column: 3}, "config.yml:2:3: a"},
+	}
+	for _, test := range tests {
+		if got := test.key.String(); got != test.want {
+			t.Errorf("got %q, want %q", got, test.want)
+		}
+	}
+
+	err := &UnknownKeysError{Keys: []UnknownKey{{Key: "a"}, {Key: "b"}}}
+	if got := err.Error(); !strings.HasSuffix(got, ":\n  a\n  b") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	candidates := []string{"host", "port", "max_connections", "timeout"}
+	tests := map[string]string{
+		"hots":            "host",
+		"prot":            "port",
+		"max_connection":  "max_connections",
+		"maxconnections":  "max_connections",
+		"Timeout":         "timeout",
+		"user":            "",
+		"x":               "",
+		"completely_else": "",
+	}
+	for key, want := range tests {
+		if got := suggestKey(key, candidates); got != want {
+			t.Errorf("%v: got %q, want %q", key, got, want)
+		}
+	}
+}
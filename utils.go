@@ -8,7 +8,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,32 +40,179 @@ func (configService *ConfigService) getENVPrefix(config interface{}) string {
 	return configService.Config.ENVPrefix
 }
 
-func getConfigurationFileWithENVPrefix(file, env string) (string, time.Time, error) {
-	var (
-		envFile string
-		extname = path.Ext(file)
-	)
-
+func getFileNameWithENVPrefix(file, env string) string {
+	extname := path.Ext(file)
 	if extname == "" {
-		envFile = fmt.Sprintf("%v.%v", file, env)
-	} else {
-		envFile = fmt.Sprintf("%v.%v%v", strings.TrimSuffix(file, extname), env, extname)
+		return fmt.Sprintf("%v.%v", file, env)
 	}
+	return fmt.Sprintf("%v.%v%v", strings.TrimSuffix(file, extname), env, extname)
+}
 
+func getConfigurationFileWithENVPrefix(file, env string) (string, time.Time, error) {
+	envFile := getFileNameWithENVPrefix(file, env)
 	if fileInfo, err := os.Stat(envFile); err == nil && fileInfo.Mode().IsRegular() {
 		return envFile, fileInfo.ModTime(), nil
 	}
 	return "", time.Now(), fmt.Errorf("failed to find file %v", file)
 }
 
+// getOverrideSuffixes returns the suffixes of the files overriding a
+// configuration file in the order they are applied: the environments, the
+// environments with the hostname, the instance ID and the environments with
+// the instance ID
+func (configService *ConfigService) getOverrideSuffixes() []string {
+	environments := configService.GetEnvironments()
+	suffixes := append([]string{}, environments...)
+
+	if hostname := configService.GetHostname(); hostname != "" {
+		for _, env := range environments {
+			suffixes = append(suffixes, env+"."+hostname)
+		}
+	}
+
+	if instanceID := configService.GetInstanceID(); instanceID != "" {
+		suffixes = append(suffixes, instanceID)
+		for _, env := range environments {
+			suffixes = append(suffixes, env+"."+instanceID)
+		}
+	}
+	return suffixes
+}
+
+// expandConfigurationFiles replaces glob patterns and directories with the
+// configuration files they contain. The files are returned in reverse lexical
+// order, so the last file takes precedence like the first file passed to Load.
+func (configService *ConfigService) expandConfigurationFiles(files []string) []string {
+	var result []string
+
+	for _, file := range files {
+		var matches []string
+
+		if strings.ContainsAny(file, "*?[") {
+			matches, _ = filepath.Glob(file)
+		} else if fileInfo, err := os.Stat(file); err == nil && fileInfo.IsDir() {
+			entries, _ := ioutil.ReadDir(file)
+			for _, entry := range entries {
+				if entry.Mode().IsRegular() && fileFormat(entry.Name()) != "" {
+					matches = append(matches, filepath.Join(file, entry.Name()))
+				}
+			}
+		} else {
+			result = append(result, file)
+			continue
+		}
+
+		matches = configService.removeVariantFiles(matches)
+		sort.Sort(sort.Reverse(sort.StringSlice(matches)))
+		if len(matches) == 0 && configService.Config.Verbose {
+			configService.logf("No configuration files found for %v\n", file)
+		}
+		result = append(result, matches...)
+	}
+	return result
+}
+
+// removeVariantFiles removes the files which are environment, host, instance
+// or example variants of other configuration files, as they are applied
+// together with the file they belong to. Variants are recognized by their
+// suffix, which is one of the current environments, those declared in
+// EnvironmentParents, the host, the instance or "example". Files with other
+// suffixes are variants too if the file they belong to is among the files,
+// e.g. db.production.yml next to db.yml when running another environment.
+// Other dotted names, e.g. app.logging.yml, are configuration files of their
+// own.
+func (configService *ConfigService) removeVariantFiles(files []string) []string {
+	suffixes := append(configService.getOverrideSuffixes(), "example")
+	for env, parent := range configService.EnvironmentParents {
+		suffixes = append(suffixes, env, parent)
+	}
+	isFile := map[string]bool{}
+	for _, file := range files {
+		isFile[file] = true
+	}
+
+	var result []string
+	for _, file := range files {
+		extname := path.Ext(file)
+		stem := strings.TrimSuffix(file, extname)
+
+		isVariant := false
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(stem, "."+suffix) {
+				isVariant = true
+				break
+			}
+		}
+		if isVariant {
+			continue
+		}
+
+		if base := variantBase(stem, extname, isFile); base != "" {
+			if !configService.Silent {
+				configService.logf("Warning: skipping %v, it is a variant of %v for another environment\n", file, base)
+			}
+			continue
+		}
+		result = append(result, file)
+	}
+	return result
+}
+
+// variantBase returns the file among files which the file stem+extname is a
+// variant of, or an empty string
+func variantBase(stem, extname string, isFile map[string]bool) string {
+	name := strings.LastIndexAny(stem, "/"+string(filepath.Separator)) + 1
+	for index := strings.LastIndex(stem, "."); index > name; index = strings.LastIndex(stem[:index], ".") {
+		if base := stem[:index] + extname; isFile[base] {
+			return base
+		}
+	}
+	return ""
+}
+
+// setConfigModTimes stores the modification times of the loaded files and
+// those included by them
+func (configService *ConfigService) setConfigModTimes(modTimes, includedModTimes map[string]time.Time) {
+	configService.includedFiles = map[string]bool{}
+	for file, modTime := range includedModTimes {
+		modTimes[file] = modTime
+		configService.includedFiles[file] = true
+	}
+	configService.configModTimes = modTimes
+}
+
+// configFilesChanged reports whether configuration files were added, removed
+// or modified since they were loaded
+func (configService *ConfigService) configFilesChanged(modTimes map[string]time.Time) bool {
+	for file := range configService.includedFiles {
+		// missing optional includes are tracked with a zero time
+		modTimes[file] = time.Time{}
+		if fileInfo, err := os.Stat(file); err == nil {
+			modTimes[file] = fileInfo.ModTime()
+		}
+	}
+
+	if len(modTimes) != len(configService.configModTimes) {
+		return true
+	}
+
+	for file, modTime := range modTimes {
+		if loaded, ok := configService.configModTimes[file]; !ok || !modTime.Equal(loaded) {
+			return true
+		}
+	}
+	return false
+}
+
 func (configService *ConfigService) getConfigurationFiles(watchMode bool, files ...string) ([]string, map[string]time.Time) {
 	var resultKeys []string
 	var results = map[string]time.Time{}
 
 	if !watchMode && (configService.Config.Debug || configService.Config.Verbose) {
-		fmt.Printf("Current environment: '%v'\n", configService.GetEnvironment())
+		configService.logf("Current environment: '%v'\n", strings.Join(configService.GetEnvironments(), "', '"))
 	}
 
+	files = configService.expandConfigurationFiles(files)
 	for i := len(files) - 1; i >= 0; i-- {
 		foundFile := false
 		file := files[i]
@@ -75,74 +224,150 @@ func (configService *ConfigService) getConfigurationFiles(watchMode bool, files
 			results[file] = fileInfo.ModTime()
 		}
 
-		// check configuration with env
-		if file, modTime, err := getConfigurationFileWithENVPrefix(file, configService.GetEnvironment()); err == nil {
-			foundFile = true
-			resultKeys = append(resultKeys, file)
-			results[file] = modTime
+		// check configuration with env, host and instance
+		for _, env := range configService.getOverrideSuffixes() {
+			if file, modTime, err := getConfigurationFileWithENVPrefix(file, env); err == nil {
+				foundFile = true
+				resultKeys = append(resultKeys, file)
+				results[file] = modTime
+			}
 		}
 
 		// check example configuration
 		if !foundFile {
 			if example, modTime, err := getConfigurationFileWithENVPrefix(file, "example"); err == nil {
 				if !watchMode && !configService.Silent {
-					fmt.Printf("Failed to find configuration %v, using example file %v\n", file, example)
+					configService.logf("Failed to find configuration %v, using example file %v\n", file, example)
 				}
 				resultKeys = append(resultKeys, example)
 				results[example] = modTime
 			} else if !configService.Silent {
-				fmt.Printf("Failed to find configuration %v\n", file)
+				configService.logf("Failed to find configuration %v\n", file)
 			}
 		}
 	}
 	return resultKeys, results
 }
 
-func processFile(config interface{}, file string, errorOnUnmatchedKeys bool) error {
-	data, err := ioutil.ReadFile(file)
+// processFile decodes a configuration file into config. The modification
+// times of included files are added to includedModTimes.
+func (configService *ConfigService) processFile(config interface{}, fsys fileSystem, file string, validator *schemaValidator, includedModTimes map[string]time.Time) error {
+	data, err := fsys.ReadFile(file)
 	if err != nil {
 		return err
 	}
+	return configService.processData(config, fsys, file, data, fileFormat(file), validator, includedModTimes)
+}
+
+// processData decodes configuration data of the given format into config. The
+// format is detected if it is empty. The data is decoded into a generic
+// document first, which is encoded again if it is transformed.
+func (configService *ConfigService) processData(config interface{}, fsys fileSystem, file string, data []byte, format Format, validator *schemaValidator, includedModTimes map[string]time.Time) error {
+	format = configService.dataFormat(format, data)
+
+	source := data
+	locate := func(path []string) (int, int, bool) {
+		return locateKey(source, format, path)
+	}
 
-	switch {
-	case strings.HasSuffix(file, ".yaml") || strings.HasSuffix(file, ".yml"):
-		if errorOnUnmatchedKeys {
-			return yaml.UnmarshalStrict(data, config)
+	// undecodable data is reported by unmarshalData
+	var operations []mergeOperation
+	var transformed interface{}
+	errorOnUnmatchedKeys := configService.GetErrorOnUnmatchedKeys()
+	if document, documentFormat, err := decodeDocument(format, data); err == nil {
+		migrated, err := configService.migrateDocument(file, document)
+		if err != nil {
+			return err
 		}
-		return yaml.Unmarshal(data, config)
-	case strings.HasSuffix(file, ".toml"):
-		return unmarshalToml(data, config, errorOnUnmatchedKeys)
-	case strings.HasSuffix(file, ".json"):
-		return unmarshalJSON(data, config, errorOnUnmatchedKeys)
-	default:
 
-		if err := unmarshalToml(data, config, errorOnUnmatchedKeys); err == nil {
-			return nil
-		} else if errUnmatchedKeys, ok := err.(*UnmatchedTomlKeysError); ok {
-			return errUnmatchedKeys
+		document, modified, err := configService.resolveIncludes(fsys, file, document, nil, includedModTimes)
+		if err != nil {
+			return err
 		}
+		modified = modified || migrated
 
-		if err := unmarshalJSON(data, config, errorOnUnmatchedKeys); err == nil {
-			return nil
-		} else if strings.Contains(err.Error(), "json: unknown field") {
+		if configService.Config.EnvironmentSections {
+			var merged bool
+			document, locate, merged = configService.mergeEnvironmentSections(file, document, locate)
+			modified = modified || merged
+		}
+
+		renamed, locateRenamed, err := configService.resolveAliases(config, file, documentFormat, document, locate)
+		if err != nil {
 			return err
 		}
+		locate, modified = locateRenamed, modified || renamed
 
-		var yamlError error
-		if errorOnUnmatchedKeys {
-			yamlError = yaml.UnmarshalStrict(data, config)
-		} else {
-			yamlError = yaml.Unmarshal(data, config)
+		if registered := lookupFormat(documentFormat); registered != nil && registered.stringTree {
+			document = shapeStringTree(reflect.TypeOf(config), document)
+		}
+
+		if documentMap, ok := document.(map[string]interface{}); ok {
+			var unset bool
+			operations, unset = collectMergeOperations(reflect.TypeOf(config), documentMap, nil, documentFormat)
+			modified = modified || unset
+		}
+
+		if validator != nil {
+			if err := validator.validateDocument(file, document, locate); err != nil {
+				return err
+			}
+		}
+
+		if errorOnUnmatchedKeys || configService.Config.WarnOnUnmatchedKeys {
+			if err := configService.checkUnknownKeys(config, file, documentFormat, document, locate); err != nil {
+				return err
+			}
+			// the keys are checked already, consistently for all formats
+			errorOnUnmatchedKeys = false
+		}
+
+		if modified {
+			if data, err = encodeDocument(documentFormat, document); err != nil {
+				return err
+			}
+			format, transformed = documentFormat, document
+		}
+	} else if registered := lookupFormat(format); registered != nil && registered.unknownKeys != nil && (errorOnUnmatchedKeys || configService.Config.WarnOnUnmatchedKeys) {
+		if err := configService.reportUnknownKeys(file, registered.unknownKeys(data, reflect.TypeOf(config))); err != nil {
+			return err
+		}
+		errorOnUnmatchedKeys = false
+	}
+
+	prepareMerge(config, operations)
+	if err := unmarshalData(config, format, data, errorOnUnmatchedKeys); err != nil {
+		if _, _, path := errorPosition(format, data, err); transformed != nil && path == nil {
+			// positions inside the encoded document don't help, look for the
+			// value which can't be decoded instead
+			if keys := documentMismatch(reflect.TypeOf(config), transformed, nil, format); keys != nil {
+				err = &keyError{keys: keys, err: err}
+			}
 		}
+		return newDecodeError(file, format, data, source, err, locate)
+	}
+	applyMerge(config, operations)
+	return nil
+}
 
-		if yamlError == nil {
+// unmarshalData decodes data into config with the decoder of the format,
+// which is detected if it is empty
+func unmarshalData(config interface{}, format Format, data []byte, errorOnUnmatchedKeys bool) error {
+	if format == "" {
+		// data holding nothing but comments is empty in every format
+		if firstContentLine(data) == "" {
 			return nil
-		} else if yErr, ok := yamlError.(*yaml.TypeError); ok {
-			return yErr
 		}
+		if format = detectFormat(data); format == "" {
+			return errors.New("failed to decode config")
+		}
+	}
 
-		return errors.New("failed to decode config")
+	registered := lookupFormat(format)
+	if registered == nil {
+		return fmt.Errorf("unknown format %v", format)
 	}
+	return registered.decoder(data, config, errorOnUnmatchedKeys)
 }
 
 // GetStringTomlKeys returns a string array of the names of the keys that are passed in as args
@@ -160,6 +385,16 @@ func unmarshalToml(data []byte, config interface{}, errorOnUnmatchedKeys bool) e
 	if err == nil && len(metadata.Undecoded()) > 0 && errorOnUnmatchedKeys {
 		return &UnmatchedTomlKeysError{Keys: metadata.Undecoded()}
 	}
+	if err != nil {
+		// type errors of toml don't tell the key, look for a value which
+		// doesn't fit its field
+		var document map[string]interface{}
+		if _, decodeErr := toml.Decode(string(data), &document); decodeErr == nil {
+			if keys := documentMismatch(reflect.TypeOf(config), normalizeValue(document), nil, FormatTOML); keys != nil {
+				return &keyError{keys: keys, err: err}
+			}
+		}
+	}
 	return err
 }
 
@@ -181,6 +416,80 @@ func unmarshalJSON(data []byte, config interface{}, errorOnUnmatchedKeys bool) e
 	return nil
 }
 
+// setFieldFromString sets the field to the value given as string, the way
+// values from the shell environment are read
+func setFieldFromString(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.Bool:
+		switch strings.ToLower(value) {
+		case "", "0", "f", "false":
+			field.SetBool(false)
+		default:
+			field.SetBool(true)
+		}
+	case reflect.String:
+		field.SetString(value)
+	default:
+		return yaml.Unmarshal([]byte(value), field.Addr().Interface())
+	}
+	return nil
+}
+
+// copyValue returns a deep copy of value. Unexported fields are copied
+// shallowly.
+func copyValue(value reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		result := reflect.New(value.Type().Elem())
+		result.Elem().Set(copyValue(value.Elem()))
+		return result
+	case reflect.Interface:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		result := reflect.New(value.Type()).Elem()
+		result.Set(copyValue(value.Elem()))
+		return result
+	case reflect.Slice:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(copyValue(value.Index(i)))
+		}
+		return result
+	case reflect.Array:
+		result := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			result.Index(i).Set(copyValue(value.Index(i)))
+		}
+		return result
+	case reflect.Map:
+		if value.IsNil() {
+			return reflect.Zero(value.Type())
+		}
+		result := reflect.MakeMap(value.Type())
+		for _, key := range value.MapKeys() {
+			result.SetMapIndex(key, copyValue(value.MapIndex(key)))
+		}
+		return result
+	case reflect.Struct:
+		result := reflect.New(value.Type()).Elem()
+		result.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if result.Field(i).CanSet() {
+				result.Field(i).Set(copyValue(value.Field(i)))
+			}
+		}
+		return result
+	}
+	return value
+}
+
 func getPrefixForStruct(prefixes []string, fieldStruct *reflect.StructField) []string {
 	if fieldStruct.Anonymous && fieldStruct.Tag.Get("anonymous") == "true" {
 		return prefixes
@@ -188,6 +497,58 @@ func getPrefixForStruct(prefixes []string, fieldStruct *reflect.StructField) []s
 	return append(prefixes, fieldStruct.Name)
 }
 
+// processEnvField loads the field from the first of its environment
+// variables which is set and reports the deprecated ones
+func (configService *ConfigService) processEnvField(configType reflect.Type, fieldStruct reflect.StructField, field reflect.Value, prefixes []string) error {
+	var envNames []string
+	// read configuration from shell env
+	if envName := fieldStruct.Tag.Get("env"); envName == "" {
+		envNames = append(envNames, strings.Join(append(prefixes, fieldStruct.Name), "_"))                  // ConfigService_DB_Name
+		envNames = append(envNames, strings.ToUpper(strings.Join(append(prefixes, fieldStruct.Name), "_"))) // CONFIGOR_DB_NAME
+		for _, alias := range fieldAliases(&fieldStruct) {
+			envNames = append(envNames, strings.Join(append(prefixes, alias), "_"), strings.ToUpper(strings.Join(append(prefixes, alias), "_")))
+		}
+	} else {
+		envNames = []string{envName}
+	}
+
+	if configService.envNames != nil {
+		for _, env := range envNames {
+			configService.envNames[env] = true
+		}
+	}
+
+	if configService.Config.Verbose {
+		configService.logf("Trying to load struct `%v`'s field `%v` from env %v\n", configType.Name(), fieldStruct.Name, strings.Join(envNames, ", "))
+	}
+
+	// Load From Shell ENV
+	for i, env := range envNames {
+		if value, _ := configService.lookupEnv(env); value != "" {
+			if configService.Config.Debug || configService.Config.Verbose {
+				configService.logf("Loading configuration for struct `%v`'s field `%v` from env %v...\n", configType.Name(), fieldStruct.Name, env)
+			}
+
+			// names after the first two are aliases
+			warning := DeprecationWarning{Key: env, Message: fieldStruct.Tag.Get("deprecated")}
+			if i > 1 {
+				warning.Replacement = envNames[i%2]
+			}
+			if warning.Replacement != "" || warning.Message != "" {
+				if err := configService.reportDeprecations([]DeprecationWarning{warning}); err != nil {
+					return err
+				}
+			}
+
+			if err := setFieldFromString(field, value); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	return nil
+}
+
 func (configService *ConfigService) processTags(config interface{}, prefixes ...string) error {
 	configValue := reflect.Indirect(reflect.ValueOf(config))
 	if configValue.Kind() != reflect.Struct {
@@ -197,51 +558,16 @@ func (configService *ConfigService) processTags(config interface{}, prefixes ...
 	configType := configValue.Type()
 	for i := 0; i < configType.NumField(); i++ {
 		var (
-			envNames    []string
 			fieldStruct = configType.Field(i)
 			field       = configValue.Field(i)
-			envName     = fieldStruct.Tag.Get("env") // read configuration from shell env
 		)
 
 		if !field.CanAddr() || !field.CanInterface() {
 			continue
 		}
 
-		if envName == "" {
-			envNames = append(envNames, strings.Join(append(prefixes, fieldStruct.Name), "_"))                  // ConfigService_DB_Name
-			envNames = append(envNames, strings.ToUpper(strings.Join(append(prefixes, fieldStruct.Name), "_"))) // CONFIGOR_DB_NAME
-		} else {
-			envNames = []string{envName}
-		}
-
-		if configService.Config.Verbose {
-			fmt.Printf("Trying to load struct `%v`'s field `%v` from env %v\n", configType.Name(), fieldStruct.Name, strings.Join(envNames, ", "))
-		}
-
-		// Load From Shell ENV
-		for _, env := range envNames {
-			if value := os.Getenv(env); value != "" {
-				if configService.Config.Debug || configService.Config.Verbose {
-					fmt.Printf("Loading configuration for struct `%v`'s field `%v` from env %v...\n", configType.Name(), fieldStruct.Name, env)
-				}
-
-				switch reflect.Indirect(field).Kind() {
-				case reflect.Bool:
-					switch strings.ToLower(value) {
-					case "", "0", "f", "false":
-						field.Set(reflect.ValueOf(false))
-					default:
-						field.Set(reflect.ValueOf(true))
-					}
-				case reflect.String:
-					field.Set(reflect.ValueOf(value))
-				default:
-					if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
-						return err
-					}
-				}
-				break
-			}
+		if err := configService.processEnvField(configType, fieldStruct, field, prefixes); err != nil {
+			return err
 		}
 
 		if isBlank := reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()); isBlank {
@@ -299,90 +625,117 @@ func (configService *ConfigService) processTags(config interface{}, prefixes ...
 	return nil
 }
 
-func (configService *ConfigService) load(config interface{}, watchMode bool, files ...string) (err error, changed bool) {
-	defer func() {
+// processDefaultsFiles decodes the files of DefaultsFS found for files into
+// config
+func (configService *ConfigService) processDefaultsFiles(config interface{}, files []string, validator *schemaValidator) error {
+	for _, file := range configService.getDefaultsFiles(files) {
 		if configService.Config.Debug || configService.Config.Verbose {
-			if err != nil {
-				fmt.Printf("Failed to load configuration from %v, got %v\n", files, err)
-			}
-
-			fmt.Printf("Configuration:\n  %#v\n", config)
+			configService.logf("Loading default configurations from file '%v'...\n", file)
 		}
-	}()
+		if err := configService.processFile(config, embeddedFileSystem{configService.Config.DefaultsFS}, file, validator, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	configFiles, configModTimeMap := configService.getConfigurationFiles(watchMode, files...)
+// processFiles decodes the default files and the configuration files found
+// for files into config
+func (configService *ConfigService) processFiles(config interface{}, files, configFiles []string, configModTimeMap map[string]time.Time) error {
+	validator, err := configService.getSchemaValidator(config)
+	if err != nil {
+		return err
+	}
 
-	if watchMode {
-		if len(configModTimeMap) == len(configService.configModTimes) {
-			var changed bool
-			for f, t := range configModTimeMap {
-				if v, ok := configService.configModTimes[f]; !ok || t.After(v) {
-					changed = true
-				}
-			}
+	if err := configService.processDefaultsFiles(config, files, validator); err != nil {
+		return err
+	}
 
-			if !changed {
-				return nil, false
-			}
-		}
+	// dotenv files are watched like included files
+	includedModTimes := map[string]time.Time{}
+	if err := configService.loadDotenv(includedModTimes); err != nil {
+		return err
 	}
 
 	for _, file := range configFiles {
 		if configService.Config.Debug || configService.Config.Verbose {
-			fmt.Printf("Loading configurations from file '%v'...\n", file)
+			configService.logf("Loading configurations from file '%v'...\n", file)
 		}
-		if err = processFile(config, file, configService.GetErrorOnUnmatchedKeys()); err != nil {
-			return err, true
+		if err := configService.processFile(config, diskFileSystem{}, file, validator, includedModTimes); err != nil {
+			return err
 		}
 	}
-	configService.configModTimes = configModTimeMap
+	configService.setConfigModTimes(configModTimeMap, includedModTimes)
+	return nil
+}
 
-	if prefix := configService.getENVPrefix(config); prefix == "-" {
-		err = configService.processTags(config)
-	} else {
-		err = configService.processTags(config, prefix)
+// processENVTags loads the fields of config from the shell environment and
+// their tags
+func (configService *ConfigService) processENVTags(config interface{}) error {
+	prefix := configService.getENVPrefix(config)
+	if prefix == "-" {
+		return configService.processTags(config)
 	}
 
-	return err, true
+	if !configService.Config.ErrorOnUnmatchedEnv && !configService.Config.WarnOnUnmatchedEnv {
+		return configService.processTags(config, prefix)
+	}
+
+	// remember the names the fields are looked up by to find unmatched ones
+	configService.envNames = map[string]bool{}
+	defer func() {
+		configService.envNames = nil
+	}()
+	if err := configService.processTags(config, prefix); err != nil {
+		return err
+	}
+	return configService.checkUnknownEnv(prefix)
 }
 
-func (configService *ConfigService) init(config interface{}, watchMode bool, files ...string) (err error, changed bool) {
+func (configService *ConfigService) load(config interface{}, watchMode bool, files ...string) (err error, changed bool) {
 	defer func() {
 		if configService.Config.Debug || configService.Config.Verbose {
 			if err != nil {
-				fmt.Printf("Failed to load configuration from %v, got %v\n", files, err)
+				configService.logf("Failed to load configuration from %v, got %v\n", files, err)
 			}
 
-			fmt.Printf("Configuration:\n  %#v\n", config)
+			configService.logf("Configuration:\n  %#v\n", config)
 		}
 	}()
 
 	configFiles, configModTimeMap := configService.getConfigurationFiles(watchMode, files...)
 
-	if watchMode {
-		if len(configModTimeMap) == len(configService.configModTimes) {
-			var changed bool
-			for f, t := range configModTimeMap {
-				if v, ok := configService.configModTimes[f]; !ok || t.After(v) {
-					changed = true
-				}
-			}
+	if watchMode && !configService.configFilesChanged(configModTimeMap) {
+		return nil, false
+	}
 
-			if !changed {
-				return nil, false
-			}
-		}
+	if err = configService.processFiles(config, files, configFiles, configModTimeMap); err != nil {
+		return err, true
 	}
 
-	for _, file := range configFiles {
+	return configService.processENVTags(config), true
+}
+
+func (configService *ConfigService) init(config interface{}, watchMode bool, files ...string) (err error, changed bool) {
+	defer func() {
 		if configService.Config.Debug || configService.Config.Verbose {
-			fmt.Printf("Loading configurations from file '%v'...\n", file)
-		}
-		if err = processFile(config, file, configService.GetErrorOnUnmatchedKeys()); err != nil {
-			return err, true
+			if err != nil {
+				configService.logf("Failed to load configuration from %v, got %v\n", files, err)
+			}
+
+			configService.logf("Configuration:\n  %#v\n", config)
 		}
+	}()
+
+	configFiles, configModTimeMap := configService.getConfigurationFiles(watchMode, files...)
+
+	if watchMode && !configService.configFilesChanged(configModTimeMap) {
+		return nil, false
+	}
+
+	if err = configService.processFiles(config, files, configFiles, configModTimeMap); err != nil {
+		return err, true
 	}
-	configService.configModTimes = configModTimeMap
 
 	if prefix := configService.getENVPrefix(config); prefix == "-" {
 		err = configService.processInitTags(config)
@@ -402,51 +755,16 @@ func (configService *ConfigService) processInitTags(config interface{}, prefixes
 	configType := configValue.Type()
 	for i := 0; i < configType.NumField(); i++ {
 		var (
-			envNames    []string
 			fieldStruct = configType.Field(i)
 			field       = configValue.Field(i)
-			envName     = fieldStruct.Tag.Get("env") // read configuration from shell env
 		)
 
 		if !field.CanAddr() || !field.CanInterface() {
 			continue
 		}
 
-		if envName == "" {
-			envNames = append(envNames, strings.Join(append(prefixes, fieldStruct.Name), "_"))                  // ConfigService_DB_Name
-			envNames = append(envNames, strings.ToUpper(strings.Join(append(prefixes, fieldStruct.Name), "_"))) // CONFIGOR_DB_NAME
-		} else {
-			envNames = []string{envName}
-		}
-
-		if configService.Config.Verbose {
-			fmt.Printf("Trying to load struct `%v`'s field `%v` from env %v\n", configType.Name(), fieldStruct.Name, strings.Join(envNames, ", "))
-		}
-
-		// Load From Shell ENV
-		for _, env := range envNames {
-			if value := os.Getenv(env); value != "" {
-				if configService.Config.Debug || configService.Config.Verbose {
-					fmt.Printf("Loading configuration for struct `%v`'s field `%v` from env %v...\n", configType.Name(), fieldStruct.Name, env)
-				}
-
-				switch reflect.Indirect(field).Kind() {
-				case reflect.Bool:
-					switch strings.ToLower(value) {
-					case "", "0", "f", "false":
-						field.Set(reflect.ValueOf(false))
-					default:
-						field.Set(reflect.ValueOf(true))
-					}
-				case reflect.String:
-					field.Set(reflect.ValueOf(value))
-				default:
-					if err := yaml.Unmarshal([]byte(value), field.Addr().Interface()); err != nil {
-						return err
-					}
-				}
-				break
-			}
+		if err := configService.processEnvField(configType, fieldStruct, field, prefixes); err != nil {
+			return err
 		}
 
 		if isBlank := reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()); isBlank {
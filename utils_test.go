@@ -0,0 +1,158 @@
+package configService
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestFiles writes the files, given by their slash separated names
+// relative to a temporary directory, and returns the directory
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		file := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// testLogger collects the logged messages
+type testLogger struct {
+	messages []string
+}
+
+func (logger *testLogger) Printf(format string, v ...interface{}) {
+	logger.messages = append(logger.messages, fmt.Sprintf(format, v...))
+}
+
+func (logger *testLogger) String() string {
+	return strings.Join(logger.messages, "")
+}
+
+func TestLoadConfDirectory(t *testing.T) {
+	type appConfig struct {
+		DB struct {
+			Host string
+			Port int
+		}
+		Logging struct {
+			Level string
+		}
+		Debug bool
+	}
+
+	dir := writeTestFiles(t, map[string]string{
+		"conf.d/db.yml":             "db:\n  host: localhost\n  port: 1\n",
+		"conf.d/db.development.yml": "db:\n  port: 2\n",
+		"conf.d/db.production.yml":  "db:\n  host: prod\n  port: 3\n",
+		"conf.d/app.logging.yml":    "logging:\n  level: info\n",
+		"conf.d/debug.example.yml":  "debug: true\n",
+		"conf.d/notes.txt":          "debug: true\n",
+	})
+
+	logger := &testLogger{}
+	var config appConfig
+	service := New(&Config{Environment: "development", Logger: logger})
+	if err := service.Load(&config, filepath.Join(dir, "conf.d")); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.DB.Host != "localhost" || config.DB.Port != 2 {
+		t.Errorf("got db %+v, want the base file with its development variant", config.DB)
+	}
+	if config.Logging.Level != "info" {
+		t.Errorf("got logging %+v, want app.logging.yml to be loaded", config.Logging)
+	}
+	if config.Debug {
+		t.Errorf("example and unsupported files were loaded")
+	}
+	if !strings.Contains(logger.String(), "db.production.yml, it is a variant of") {
+		t.Errorf("got log %q, want a warning about db.production.yml", logger)
+	}
+}
+
+func TestRemoveVariantFiles(t *testing.T) {
+	service := New(&Config{Environment: "test", EnvironmentParents: map[string]string{"staging": "production"}, Silent: true})
+	files := []string{
+		"conf.d/app.yml",
+		"conf.d/app.test.yml",
+		"conf.d/app.staging.yml",
+		"conf.d/app.eu.yml",
+		"conf.d/app.example.yml",
+		"conf.d/cache.production.json",
+		"conf.d/cache.eu.json",
+		"conf.d/app.logging.yml",
+		"conf.d/app.logging.eu.yml",
+		"conf.d/.hidden.yml",
+		"conf.d.yml",
+	}
+
+	got := service.removeVariantFiles(files)
+	want := []string{"conf.d/app.yml", "conf.d/cache.eu.json", "conf.d/.hidden.yml", "conf.d.yml"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestLoadAndInitFromEnvAliases(t *testing.T) {
+	type appConfig struct {
+		DB struct {
+			Host string `alias:"hostname"`
+			Port int    `env:"TEST_DB_PORT"`
+		}
+	}
+
+	os.Setenv("TESTAPP_DB_HOSTNAME", "env-host")
+	os.Setenv("TEST_DB_PORT", "5432")
+	defer os.Unsetenv("TESTAPP_DB_HOSTNAME")
+	defer os.Unsetenv("TEST_DB_PORT")
+
+	for name, load := range map[string]func(*ConfigService, interface{}) error{
+		"Load": func(service *ConfigService, config interface{}) error { return service.Load(config) },
+		"Init": func(service *ConfigService, config interface{}) error { return service.Init(config) },
+	} {
+		var warnings []DeprecationWarning
+		var config appConfig
+		service := New(&Config{ENVPrefix: "TestApp", DeprecationHandler: func(warning DeprecationWarning) {
+			warnings = append(warnings, warning)
+		}})
+		if err := load(service, &config); err != nil {
+			t.Fatalf("%v: %v", name, err)
+		}
+
+		if config.DB.Host != "env-host" || config.DB.Port != 5432 {
+			t.Errorf("%v: got %+v", name, config.DB)
+		}
+		if len(warnings) != 1 || warnings[0].Key != "TESTAPP_DB_HOSTNAME" || warnings[0].Replacement != "TESTAPP_DB_HOST" {
+			t.Errorf("%v: got warnings %+v, want TESTAPP_DB_HOSTNAME to be deprecated", name, warnings)
+		}
+	}
+}
+
+// rewriteTestFile replaces the content of file and moves its modification
+// time forward, so an automatic reload notices the change
+func rewriteTestFile(t *testing.T, file, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fileInfo, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := fileInfo.ModTime().Add(time.Minute)
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
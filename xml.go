@@ -0,0 +1,294 @@
+package configService
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// FormatXML is the format of XML files, which are decoded with encoding/xml
+// and its xml struct tags. Repeated elements of a file replace the lists
+// set by earlier files. Includes, environment sections, merge strategies,
+// aliases and schema validation don't apply to XML files.
+const FormatXML Format = "xml"
+
+func init() {
+	registerFormat(&registeredFormat{
+		name:       FormatXML,
+		extensions: []string{".xml"},
+		decoder:    unmarshalXML,
+		encoder:    marshalXML,
+		decodeDocument: func([]byte) (interface{}, error) {
+			return nil, errors.New("xml can't be decoded into a generic document")
+		},
+		unknownKeys: unknownXMLElements,
+	})
+	RegisterSniffer(string(FormatXML), sniffXML)
+}
+
+func sniffXML(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("<"))
+}
+
+func unmarshalXML(data []byte, config interface{}, errorOnUnmatchedKeys bool) error {
+	elements, err := scanXMLElements(data)
+	if err != nil {
+		return err
+	}
+
+	// encoding/xml appends repeated elements, the lists of a file replace
+	// those of earlier files instead
+	present := map[string]bool{}
+	for _, element := range elements {
+		present[strings.Join(element.path, ">")] = true
+	}
+	resetXMLSlices(reflect.ValueOf(config), present, nil)
+
+	if err := xml.Unmarshal(data, config); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
+
+// xmlElement is an element of XML data below the root element
+type xmlElement struct {
+	// path holds the names of the element and its parents
+	path   []string
+	offset int64
+}
+
+func scanXMLElements(data []byte) ([]xmlElement, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var elements []xmlElement
+	var path []string
+	depth := 0
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return elements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			if depth > 0 {
+				path = append(path, token.Name.Local)
+				elements = append(elements, xmlElement{path: append([]string{}, path...), offset: offset})
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth > 0 {
+				path = path[:len(path)-1]
+			}
+		}
+	}
+}
+
+// unknownXMLElements returns the elements of data which don't match any
+// field of the config type. Elements inside unknown ones aren't reported.
+func unknownXMLElements(data []byte, t reflect.Type) []UnknownKey {
+	// syntax errors are reported by the decoder
+	elements, _ := scanXMLElements(data)
+
+	var keys []UnknownKey
+	reported := map[string]bool{}
+	for _, element := range elements {
+		key := strings.Join(element.path, ".")
+		if reported[strings.Join(element.path[:len(element.path)-1], ".")] {
+			reported[key] = true
+			continue
+		}
+		known, parent, rest := lookupXMLElement(t, element.path)
+		if known || reported[key] {
+			continue
+		}
+		reported[key] = true
+
+		unknown := UnknownKey{Key: key}
+		unknown.Line, unknown.Column = offsetPosition(data, int(element.offset))
+		if parent != nil {
+			// fields of the parent holding elements next to the unknown one
+			var names []string
+			for _, field := range xmlElementFields(parent) {
+				if len(field.path) >= len(rest) && equalStrings(field.path[:len(rest)-1], rest[:len(rest)-1]) {
+					names = append(names, field.path[len(rest)-1])
+				}
+			}
+			if suggestion := suggestKey(rest[len(rest)-1], names); suggestion != "" {
+				unknown.Suggestion = strings.Join(append(append([]string{}, element.path[:len(element.path)-1]...), suggestion), ".")
+			}
+		}
+		keys = append(keys, unknown)
+	}
+	return keys
+}
+
+// lookupXMLElement reports whether encoding/xml decodes the element at path
+// into a field of the type. If it doesn't, the struct type the element
+// would have to be a field of and the path of the element inside it are
+// returned, unless it isn't a struct.
+func lookupXMLElement(t reflect.Type, path []string) (bool, reflect.Type, []string) {
+	for t.Kind() == reflect.Ptr || (t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8) || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if len(path) == 0 || decodesItself(t) || reflect.PtrTo(t).Implements(xmlUnmarshalerType) {
+		return true, nil, nil
+	}
+	if t.Kind() != reflect.Struct {
+		return false, nil, nil
+	}
+
+	for _, field := range xmlElementFields(t) {
+		if field.any {
+			return true, nil, nil
+		}
+		if len(path) <= len(field.path) {
+			if equalStrings(field.path[:len(path)], path) {
+				// the element itself or one of its parents
+				return true, nil, nil
+			}
+			continue
+		}
+		if equalStrings(field.path, path[:len(field.path)]) {
+			return lookupXMLElement(t.FieldByIndex(field.index).Type, path[len(field.path):])
+		}
+	}
+	return false, t, path
+}
+
+var xmlUnmarshalerType = reflect.TypeOf((*xml.Unmarshaler)(nil)).Elem()
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// xmlElementField describes a struct field holding elements
+type xmlElementField struct {
+	index []int
+	// path holds the element names of the field, e.g. a>b
+	path []string
+	// any is set for fields taking all elements which aren't matched
+	any bool
+}
+
+// xmlElementFields returns the fields of a struct holding elements,
+// including those of embedded structs
+func xmlElementFields(t reflect.Type) []xmlElementField {
+	var fields []xmlElementField
+	for i := 0; i < t.NumField(); i++ {
+		fieldStruct := t.Field(i)
+		if (fieldStruct.PkgPath != "" && !fieldStruct.Anonymous) || fieldStruct.Name == "XMLName" {
+			continue
+		}
+
+		options := strings.Split(fieldStruct.Tag.Get("xml"), ",")
+		name := options[0]
+		if name == "-" {
+			continue
+		}
+
+		field := xmlElementField{index: fieldStruct.Index}
+		for _, option := range options[1:] {
+			switch option {
+			case "attr", "chardata", "comment", "cdata":
+				field.index = nil
+			case "any", "innerxml":
+				field.any = true
+			}
+		}
+		if field.index == nil {
+			continue
+		}
+
+		fieldType := fieldStruct.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldStruct.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for _, embedded := range xmlElementFields(fieldType) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+		if fieldStruct.PkgPath != "" {
+			continue
+		}
+
+		if name == "" {
+			name = fieldStruct.Name
+		}
+		field.path = strings.Split(name, ">")
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// resetXMLSlices clears the slices of the fields holding the present
+// element paths
+func resetXMLSlices(value reflect.Value, present map[string]bool, path []string) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, field := range xmlElementFields(value.Type()) {
+		fieldPath := append(append([]string{}, path...), field.path...)
+		if field.any || !present[strings.Join(fieldPath, ">")] {
+			continue
+		}
+
+		fieldValue := xmlFieldValue(value, field.index)
+		if !fieldValue.IsValid() || !fieldValue.CanSet() {
+			continue
+		}
+		if fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() != reflect.Uint8 {
+			fieldValue.Set(reflect.Zero(fieldValue.Type()))
+		} else {
+			resetXMLSlices(fieldValue, present, fieldPath)
+		}
+	}
+}
+
+func marshalXML(config interface{}) ([]byte, error) {
+	data, err := xml.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// xmlFieldValue returns the field at index, or an invalid value if an
+// embedded pointer on the way is nil
+func xmlFieldValue(value reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	return value
+}
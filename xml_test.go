@@ -0,0 +1,163 @@
+package configService
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type xmlTestConfig struct {
+	XMLName xml.Name `xml:"config"`
+	Name    string   `xml:"name,attr"`
+	Hosts   []string `xml:"servers>host"`
+	Ports   []int    `xml:"port"`
+	Key     []byte   `xml:"key"`
+	Server  struct {
+		Listen string `xml:"listen"`
+	} `xml:"server"`
+	*xmlTestLimits
+}
+
+type xmlTestLimits struct {
+	Limits []int `xml:"limit"`
+}
+
+func TestLoadXMLReplacesLists(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"config.xml":   "<config name=\"app\">\n  <servers><host>a</host><host>b</host></servers>\n  <port>1</port>\n  <port>2</port>\n</config>\n",
+		"override.xml": "<config>\n  <port>3</port>\n</config>\n",
+	})
+
+	config := &xmlTestConfig{}
+	service := New(&Config{Silent: true})
+	if err := service.Load(config, filepath.Join(dir, "override.xml"), filepath.Join(dir, "config.xml")); err != nil {
+		t.Fatal(err)
+	}
+	if config.Name != "app" || !reflect.DeepEqual(config.Hosts, []string{"a", "b"}) || !reflect.DeepEqual(config.Ports, []int{3}) {
+		t.Errorf("got %+v, want the ports of override.xml to replace those of config.xml", config)
+	}
+
+	// loading again doesn't append to the lists of the first load
+	if err := service.Load(config, filepath.Join(dir, "override.xml"), filepath.Join(dir, "config.xml")); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config.Hosts, []string{"a", "b"}) || !reflect.DeepEqual(config.Ports, []int{3}) {
+		t.Errorf("got %+v after loading again", config)
+	}
+}
+
+func TestSaveXML(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.xml")
+
+	config := &xmlTestConfig{Name: "app", Hosts: []string{"a"}, Ports: []int{1, 2}, Key: []byte("k")}
+	config.Server.Listen = ":80"
+	if err := New(nil).Save(config, file); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), xml.Header+`<config name="app">`) {
+		t.Errorf("got\n%s", data)
+	}
+
+	loaded := &xmlTestConfig{}
+	if err := New(&Config{Silent: true}).Load(loaded, file); err != nil {
+		t.Fatal(err)
+	}
+	config.XMLName.Local = "config"
+	if !reflect.DeepEqual(loaded, config) {
+		t.Errorf("got %+v, want %+v", loaded, config)
+	}
+}
+
+func TestResetXMLSlices(t *testing.T) {
+	tests := []struct {
+		name    string
+		present []string
+		check   func(*xmlTestConfig) bool
+	}{
+		{"nothing present", nil, func(config *xmlTestConfig) bool {
+			return len(config.Hosts) == 1 && len(config.Ports) == 1 && len(config.Limits) == 1
+		}},
+		{"nested path", []string{"servers", "servers>host"}, func(config *xmlTestConfig) bool {
+			return config.Hosts == nil && len(config.Ports) == 1
+		}},
+		{"parent only", []string{"servers"}, func(config *xmlTestConfig) bool {
+			return len(config.Hosts) == 1
+		}},
+		{"embedded pointer", []string{"limit"}, func(config *xmlTestConfig) bool {
+			return config.Limits == nil && len(config.Ports) == 1
+		}},
+		{"bytes", []string{"key"}, func(config *xmlTestConfig) bool {
+			return string(config.Key) == "k"
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := &xmlTestConfig{Hosts: []string{"a"}, Ports: []int{1}, Key: []byte("k"), xmlTestLimits: &xmlTestLimits{Limits: []int{1}}}
+			present := map[string]bool{}
+			for _, path := range test.present {
+				present[path] = true
+			}
+			resetXMLSlices(reflect.ValueOf(config), present, nil)
+			if !test.check(config) {
+				t.Errorf("got %+v", config)
+			}
+		})
+	}
+
+	// nil embedded pointers are skipped
+	config := &xmlTestConfig{}
+	resetXMLSlices(reflect.ValueOf(config), map[string]bool{"limit": true}, nil)
+	if config.xmlTestLimits != nil {
+		t.Errorf("got %+v, want the embedded pointer to stay nil", config)
+	}
+}
+
+func TestUnknownXMLElements(t *testing.T) {
+	type anyConfig struct {
+		Name  string `xml:"name"`
+		Extra []struct {
+			XMLName xml.Name
+			Value   string `xml:",chardata"`
+		} `xml:",any"`
+	}
+
+	data := []byte("<config>\n  <servers><host>a</host><hots>b</hots></servers>\n  <prot>1</prot>\n  <sevrers/>\n  <extra><deeper><deepest/></deeper></extra>\n  <server><listen/></server>\n  <limit>1</limit>\n</config>\n")
+	keys := unknownXMLElements(data, reflect.TypeOf(xmlTestConfig{}))
+	want := []UnknownKey{
+		{Key: "servers.hots", Line: 2, Column: 26, Suggestion: "servers.host"},
+		{Key: "prot", Line: 3, Column: 3, Suggestion: "port"},
+		{Key: "sevrers", Line: 4, Column: 3, Suggestion: "servers"},
+		{Key: "extra", Line: 5, Column: 3},
+	}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("got %#v, want %#v", keys, want)
+	}
+
+	if keys := unknownXMLElements(data, reflect.TypeOf(anyConfig{})); len(keys) != 0 {
+		t.Errorf("got %+v, want elements matching ,any fields to be known", keys)
+	}
+}
+
+func TestLoadXMLUnknownElements(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{"config.xml": "<config>\n  <prot>1</prot>\n</config>\n"})
+
+	err := New(&Config{ErrorOnUnmatchedKeys: true}).Load(&xmlTestConfig{}, filepath.Join(dir, "config.xml"))
+	if _, ok := err.(*UnknownKeysError); !ok || !strings.Contains(err.Error(), "config.xml:2:3: prot, did you mean port?") {
+		t.Errorf("got %v, want an UnknownKeysError for prot", err)
+	}
+
+	logger := &testLogger{}
+	err = New(&Config{WarnOnUnmatchedKeys: true, Logger: logger}).Load(&xmlTestConfig{}, filepath.Join(dir, "config.xml"))
+	if err != nil || !strings.Contains(logger.String(), "Unknown configuration key") {
+		t.Errorf("got %v and log %q, want a warning", err, logger)
+	}
+}